@@ -0,0 +1,189 @@
+package timer
+
+import (
+	"sync"
+	"time"
+)
+
+// How long an alarm sounds before stopping on its own if no maximum is
+// configured.
+var DEFAULT_ALARM_MAX = 5 * time.Minute
+
+// Length of the countdown started by snoozing an alarm if none is
+// configured.
+var DEFAULT_SNOOZE = 5 * time.Minute
+
+// Silence between repetitions of the alarm sound.
+var ALARM_REPEAT_GAP = 500 * time.Millisecond
+
+// AlarmConfig configures alarm mode, in which the timer finish sound loops
+// until it is dismissed instead of playing once.
+type AlarmConfig struct {
+	Enabled     bool
+	Snooze      time.Duration // Length of the countdown started by snoozing, defaults to DEFAULT_SNOOZE
+	MaxDuration time.Duration // How long the alarm sounds before stopping on its own, defaults to DEFAULT_ALARM_MAX
+}
+
+// alarm plays a sound repeatedly until it is stopped, snoozed or has
+// sounded for its maximum duration.
+type alarm struct {
+	player   player
+	gap      time.Duration
+	mu       sync.Mutex
+	stopC    chan bool // Closed to stop ringing or snoozing, nil when doing neither
+	snoozing bool
+}
+
+func newAlarm(p player) *alarm {
+	return &alarm{
+		player: p,
+		gap:    ALARM_REPEAT_GAP,
+	}
+}
+
+// ring starts playing sound repeatedly, replacing any ringing or snoozed
+// alarm. Once max has elapsed the alarm stops on its own and onTimeout is
+// called.
+func (al *alarm) ring(sound audioStream, max time.Duration, onTimeout func()) {
+	al.stop()
+	stopC := make(chan bool)
+	al.mu.Lock()
+	al.stopC = stopC
+	al.mu.Unlock()
+
+	go func() {
+		deadline := time.NewTimer(max)
+		defer deadline.Stop()
+		for {
+			select {
+			case <-stopC:
+				return
+			default:
+			}
+			done := make(chan bool, 1)
+			al.player.PlaySound(sound, done)
+			select {
+			case <-done:
+			case <-stopC:
+				return
+			case <-deadline.C:
+				al.timeout(stopC, onTimeout)
+				return
+			}
+
+			gap := time.NewTimer(al.gap)
+			select {
+			case <-gap.C:
+			case <-stopC:
+				gap.Stop()
+				return
+			case <-deadline.C:
+				gap.Stop()
+				al.timeout(stopC, onTimeout)
+				return
+			}
+		}
+	}()
+}
+
+// timeout stops the alarm started with stopC and calls onTimeout, unless it
+// has already been stopped.
+func (al *alarm) timeout(stopC chan bool, onTimeout func()) {
+	if !al.release(stopC) {
+		return
+	}
+	al.player.StopAll()
+	if onTimeout != nil {
+		onTimeout()
+	}
+}
+
+// snooze silences a ringing alarm and calls onWake once d has elapsed,
+// calling onTick with the time remaining every second until then. Returns
+// false and does nothing if the alarm isn't ringing.
+func (al *alarm) snooze(d time.Duration, onTick func(remaining time.Duration), onWake func()) bool {
+	al.mu.Lock()
+	if al.stopC == nil || al.snoozing {
+		al.mu.Unlock()
+		return false
+	}
+	close(al.stopC)
+	stopC := make(chan bool)
+	al.stopC = stopC
+	al.snoozing = true
+	al.mu.Unlock()
+	al.player.StopAll()
+
+	go func() {
+		end := time.Now().Add(d)
+		wake := time.NewTimer(d)
+		defer wake.Stop()
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		if onTick != nil {
+			onTick(d)
+		}
+		for {
+			select {
+			case <-stopC:
+				return
+			case <-ticker.C:
+				if onTick != nil {
+					onTick(time.Until(end).Round(time.Second))
+				}
+			case <-wake.C:
+				if al.release(stopC) && onWake != nil {
+					onWake()
+				}
+				return
+			}
+		}
+	}()
+	return true
+}
+
+// release marks the alarm started with stopC as stopped. Returns false if it
+// had already been stopped or replaced.
+func (al *alarm) release(stopC chan bool) bool {
+	al.mu.Lock()
+	defer al.mu.Unlock()
+	if al.stopC != stopC {
+		return false
+	}
+	al.stopC = nil
+	al.snoozing = false
+	return true
+}
+
+// stop stops the alarm ringing or cancels its snooze. Returns false if it
+// was doing neither.
+func (al *alarm) stop() bool {
+	al.mu.Lock()
+	if al.stopC == nil {
+		al.mu.Unlock()
+		return false
+	}
+	close(al.stopC)
+	al.stopC = nil
+	snoozing := al.snoozing
+	al.snoozing = false
+	al.mu.Unlock()
+	if !snoozing {
+		al.player.StopAll()
+	}
+	return true
+}
+
+// ringing returns whether the alarm is sounding.
+func (al *alarm) ringing() bool {
+	al.mu.Lock()
+	defer al.mu.Unlock()
+	return al.stopC != nil && !al.snoozing
+}
+
+// snoozed returns whether the alarm is snoozed.
+func (al *alarm) snoozed() bool {
+	al.mu.Lock()
+	defer al.mu.Unlock()
+	return al.snoozing
+}
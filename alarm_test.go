@@ -0,0 +1,79 @@
+package timer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAlarmRingsUntilStopped(t *testing.T) {
+	player := &recordingPlayer{}
+	al := newAlarm(player)
+	al.gap = 10 * time.Millisecond
+
+	al.ring(audioStream{name: "Chime"}, time.Minute, nil)
+	assert.Eventually(t, func() bool { return len(player.sounds()) >= 3 }, time.Second, time.Millisecond)
+	assert.True(t, al.ringing())
+
+	assert.True(t, al.stop())
+	assert.False(t, al.ringing())
+	assert.False(t, al.stop())
+	time.Sleep(2 * al.gap)
+	played := len(player.sounds())
+	time.Sleep(5 * al.gap)
+	assert.Equal(t, played, len(player.sounds()))
+	for _, sound := range player.sounds() {
+		assert.Equal(t, "Chime", sound.name)
+	}
+}
+
+func TestAlarmStopsAfterMaxDuration(t *testing.T) {
+	player := &recordingPlayer{}
+	al := newAlarm(player)
+	al.gap = 10 * time.Millisecond
+	timedOut := make(chan bool, 1)
+
+	al.ring(audioStream{name: "Chime"}, 50*time.Millisecond, func() { timedOut <- true })
+	select {
+	case <-timedOut:
+	case <-time.After(time.Second):
+		t.Fatal("alarm didn't stop after its maximum duration")
+	}
+	assert.False(t, al.ringing())
+	assert.False(t, al.stop())
+}
+
+func TestAlarmSnooze(t *testing.T) {
+	player := &recordingPlayer{}
+	al := newAlarm(player)
+	al.gap = 10 * time.Millisecond
+
+	assert.False(t, al.snooze(time.Second, nil, nil), "can't snooze an alarm that isn't ringing")
+
+	woken := make(chan bool, 1)
+	al.ring(audioStream{name: "Chime"}, time.Minute, nil)
+	assert.Eventually(t, func() bool { return len(player.sounds()) > 0 }, time.Second, time.Millisecond)
+	assert.True(t, al.snooze(50*time.Millisecond, nil, func() { woken <- true }))
+	assert.False(t, al.ringing())
+	assert.True(t, al.snoozed())
+	time.Sleep(2 * al.gap)
+	played := len(player.sounds())
+	select {
+	case <-woken:
+	case <-time.After(time.Second):
+		t.Fatal("snoozed alarm didn't wake")
+	}
+	assert.False(t, al.snoozed())
+	assert.Equal(t, played, len(player.sounds()), "alarm shouldn't sound while snoozed")
+
+	// dismissing a snoozed alarm cancels the snooze
+	al.ring(audioStream{name: "Chime"}, time.Minute, nil)
+	assert.True(t, al.snooze(50*time.Millisecond, nil, func() { woken <- true }))
+	assert.True(t, al.stop())
+	select {
+	case <-woken:
+		t.Fatal("dismissed alarm woke from snooze")
+	case <-time.After(150 * time.Millisecond):
+	}
+}
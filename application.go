@@ -1,198 +1,970 @@
-package timer
-
-import (
-	"log"
-	"strconv"
-
-	"fyne.io/fyne/v2"
-	"fyne.io/fyne/v2/app"
-	"github.com/faiface/beep"
-	"github.com/gabriel-ross/timer-go/internal"
-)
-
-// Map for quick conversion of digit strings to int.
-var DIGIT_MAP = genDigitStringToIntMap(100)
-
-type Config struct {
-	SpeakerSampleRate           int // Speaker ratio in HZ
-	AudioBufferRatio            int // The ratio of (audio buffer size) / (sample rate)
-	MaxIntervals                int
-	MaxTimerMins                int
-	MaxTimerSecs                int
-	InitialIntervalEndSoundName string
-	InitialTimerEndSoundName    string
-}
-
-type application struct {
-	cnf                 Config
-	guiDriver           fyne.App
-	gui                 *gui
-	timerConfig         *internal.Config
-	timer               *internal.RepeatTimer
-	speakerSampleRate   beep.SampleRate
-	audioPlayer         player
-	intervalFinishSound audioStream
-	timerFinishSound    audioStream
-	sounds              map[string]audioStream
-}
-
-func New(cnf Config, options ...func(*application)) *application {
-	var err error
-	newApplication := &application{
-		cnf:               cnf,
-		guiDriver:         app.New(),
-		timerConfig:       &internal.Config{},
-		speakerSampleRate: beep.SampleRate(cnf.SpeakerSampleRate),
-		sounds:            map[string]audioStream{},
-	}
-
-	newApplication.audioPlayer, err = NewPlayer(newApplication.speakerSampleRate, cnf.AudioBufferRatio)
-	if err != nil {
-		log.Fatalf("error initializing speaker: %v", err)
-	}
-	newApplication.sounds["None"] = newApplication.audioPlayer.NilAudioStream(newApplication.speakerSampleRate)
-	var exists bool
-	newApplication.intervalFinishSound, exists = newApplication.sounds[cnf.InitialIntervalEndSoundName]
-	if !exists {
-		newApplication.intervalFinishSound = newApplication.sounds["None"]
-		newApplication.cnf.InitialIntervalEndSoundName = "None"
-	}
-	newApplication.timerFinishSound, exists = newApplication.sounds[cnf.InitialTimerEndSoundName]
-	if !exists {
-		newApplication.timerFinishSound = newApplication.sounds["None"]
-	}
-
-	for _, option := range options {
-		option(newApplication)
-	}
-
-	newApplication.gui = NewGui(newApplication)
-
-	return newApplication
-}
-
-// WithAudioFiles is a functional option for configuring the sound options
-// of an application. audios is a map where the key is the display name of the
-// sound in the application and the value is the file path where it can be
-// found. The application will crash if there are any errors registering
-// the audio files.
-func WithAudioFiles(audios map[string]string) func(*application) {
-	return func(a *application) {
-		for name, path := range audios {
-			if err := a.RegisterSound(name, path); err != nil {
-				log.Printf("error decoding audio file: %v\n", err)
-			}
-		}
-	}
-}
-
-// Run runs the application.
-func (a *application) Run() {
-	a.gui.simpleViewWindow().ShowAndRun()
-}
-
-// OnClose handles cleanup and releases resources when an application is closed.
-func (a *application) OnClose() {
-	var err error
-	for name, audio := range a.sounds {
-		if err = audio.stream.Close(); err != nil {
-			log.Printf("error closing audio %s: %v", name, err)
-		}
-	}
-}
-
-// RegisterSound decodes the mp3 file at path and registers it to the
-// application's sound map.
-func (a *application) RegisterSound(name, path string) error {
-	stream, err := a.audioPlayer.NewAudioStream(path)
-	if err != nil {
-		return err
-	}
-	a.sounds[name] = stream
-	return nil
-}
-
-func (a *application) runTimer() {
-	a.timer = internal.NewRepeatCountdownTimer(*a.timerConfig)
-	done := false
-
-	// poll for interval name update
-	go func() {
-		for !done {
-			select {
-			case name := <-a.timer.IntervalName():
-				a.gui.updateTimerName(name)
-			default:
-			}
-		}
-	}()
-
-	// poll for time remaining updates
-	go func() {
-		for !done {
-			select {
-			case update := <-a.timer.TimeRemaining():
-				a.gui.updateTimerDisplay(update)
-			default:
-			}
-		}
-	}()
-
-	// poll for interval finished updates and play sound
-	go func() {
-		for !done {
-			select {
-			case <-a.timer.IntervalFinished():
-				a.audioPlayer.PlaySound(a.speakerSampleRate, a.intervalFinishSound, nil)
-			default:
-			}
-		}
-	}()
-
-	go func() {
-		a.timer.Start()
-		a.audioPlayer.PlaySound(a.speakerSampleRate, a.timerFinishSound, nil)
-		done = true
-		a.gui.reset()
-	}()
-}
-
-func (a *application) handleTimerCancel() {
-	a.timer.Cancel()
-}
-
-func (a *application) handleTimerPause() {
-	a.timer.Pause()
-}
-
-func (a *application) handleTimerResume() {
-	a.timer.Resume()
-}
-
-func (a *application) handleTimerSkip() {
-	a.timer.Skip()
-}
-
-func (a *application) soundOptions() []string {
-	opts := []string{}
-	for optionKey := range a.sounds {
-		opts = append(opts, optionKey)
-	}
-	return opts
-}
-
-func genIncrementingDigitStringSlice(start, size int) []string {
-	s := []string{}
-	for i := start; len(s) <= size; i++ {
-		s = append(s, strconv.Itoa(i))
-	}
-	return s
-}
-
-func genDigitStringToIntMap(max int) map[string]int {
-	m := map[string]int{}
-	for i := 0; i <= max; i++ {
-		m[strconv.Itoa(i)] = i
-	}
-	return m
-}
+package timer
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/app"
+	"github.com/faiface/beep"
+	"github.com/gabriel-ross/timer-go/internal"
+	"github.com/gabriel-ross/timer-go/preset"
+)
+
+// Map for quick conversion of digit strings to int.
+var DIGIT_MAP = genDigitStringToIntMap(100)
+
+// Preference keys used to persist settings between runs.
+var (
+	PREF_MASTER_VOLUME = "volume.master"
+	PREF_SOUND_VOLUME  = "volume.sound."
+	PREF_SOUND_TRIM    = "trim.sound."
+	PREF_MUSIC_WORK    = "music.work"
+	PREF_MUSIC_REST    = "music.rest"
+	PREF_MUSIC_SHUFFLE = "music.shuffle"
+	PREF_SOUND_PACK    = "sound.pack"
+)
+
+// How much earlier than strictly necessary to request cues from the timer,
+// allowing for the time taken to receive and schedule them.
+var CUE_LEAD_MARGIN = 50 * time.Millisecond
+
+// Name shown for the time cap running alongside a program.
+var TIME_CAP_NAME = "Time cap"
+
+// The maximum gain that can be applied to an individual sound.
+var MAX_SOUND_VOLUME = 2.0
+
+type Config struct {
+	AppID                       string // Unique ID used to store preferences
+	AudioBackend                AudioBackend
+	SpeakerSampleRate           int           // Speaker ratio in HZ
+	AudioBufferRatio            int           // The ratio of (audio buffer size) / (sample rate)
+	AudioLatency                time.Duration // Output latency of the audio device on top of the speaker buffer
+	MaxIntervals                int
+	MaxTimerMins                int
+	MaxTimerSecs                int
+	InitialIntervalEndSoundName string
+	InitialTimerEndSoundName    string
+	MetronomeSoundName          string             // Sound played on each metronome beat, defaults to "Click"
+	VoicePackDir                string             // Folder containing a voice pack used for announcements
+	MasterVolume                float64            // Initial master volume between 0 and 1, defaults to 1
+	SoundVolumes                map[string]float64 // Initial gain of each sound by name, defaults to 1
+	Music                       MusicConfig        // Initial background music
+	Alarm                       AlarmConfig        // Whether and how the timer finish sound loops until dismissed
+	SoundLibraryDir             string             // Folder imported sounds are copied into, defaults to SOUND_LIBRARY_DIR in the app's storage root
+	SoundPackDir                string             // Folder containing sound packs, see SOUND_PACK_MANIFEST
+	HistoryFile                 string             // File each run is recorded in, defaults to HISTORY_FILE in the app's storage root
+}
+
+type application struct {
+	cnf                 Config
+	guiDriver           fyne.App
+	gui                 *gui
+	timerConfig         *internal.Config
+	timer               *internal.RepeatTimer
+	speakerSampleRate   beep.SampleRate
+	audioPlayer         player
+	intervalFinishSound *audioStream
+	timerFinishSound    *audioStream
+	metronomeSound      *audioStream
+	workStartSound      *audioStream // nil plays nothing
+	restStartSound      *audioStream // nil plays nothing
+	countdownSound      *audioStream // nil speaks the countdown if there is a voice pack
+	sounds              map[string]*audioStream
+	music               map[internal.Phase]*playlist
+	voice               *voicePack
+	alarm               *alarm
+	library             soundLibrary
+	program             *preset.Program // Program of segments loaded from a preset, nil when using the interval settings
+	presets             presetLibrary
+	historyFile         string
+	seed                int64  // Seed of the random choices in the current or most recent run
+	replaySeed          *int64 // Seed for the next run, nil for a new random seed
+	soundPacks          []*soundPack
+	soundPack           string
+	loadErrors          []error
+	running             atomic.Bool    // Whether the timer is running
+	runs                sync.WaitGroup // Runs of the timer that haven't ended
+	scheduleMu          sync.Mutex
+	scheduler           *internal.Scheduler // Counts down to the scheduled start, nil if there is none
+	scheduled           *ScheduledStart
+}
+
+func New(cnf Config, options ...func(*application)) *application {
+	newApplication := &application{
+		cnf:               cnf,
+		guiDriver:         newGuiDriver(cnf.AppID),
+		timerConfig:       &internal.Config{},
+		speakerSampleRate: beep.SampleRate(cnf.SpeakerSampleRate),
+		sounds:            map[string]*audioStream{},
+		music:             map[internal.Phase]*playlist{},
+	}
+
+	newApplication.audioPlayer = newPlayer(cnf.AudioBackend, newApplication.speakerSampleRate, cnf.AudioBufferRatio, cnf.AudioLatency)
+	if newApplication.cnf.MasterVolume == 0 {
+		newApplication.cnf.MasterVolume = 1
+	}
+	newApplication.audioPlayer.SetVolume(newApplication.MasterVolume())
+	newApplication.alarm = newAlarm(newApplication.audioPlayer)
+	newApplication.SetAlarmConfig(cnf.Alarm)
+	if err := newApplication.SetMusicConfig(newApplication.MusicConfig()); err != nil {
+		newApplication.loadError(fmt.Errorf("loading music: %w", err))
+	}
+	nilStream := nilAudioStream(newApplication.speakerSampleRate)
+	nilStream.name = "None"
+	newApplication.sounds["None"] = &nilStream
+	for name, tone := range DEFAULT_TONES {
+		if err := newApplication.RegisterTone(name, tone); err != nil {
+			newApplication.loadError(err)
+		}
+	}
+	// metronome clicks are too frequent to duck music for
+	newApplication.SetSoundPolicy("Click", CuePolicy{NoDuck: true})
+	newApplication.library.dir = cnf.SoundLibraryDir
+	if newApplication.library.dir == "" {
+		newApplication.library.dir = filepath.Join(newApplication.guiDriver.Storage().RootURI().Path(), SOUND_LIBRARY_DIR)
+	}
+	newApplication.loadLibrary()
+	var exists bool
+	newApplication.intervalFinishSound, exists = newApplication.sounds[cnf.InitialIntervalEndSoundName]
+	if !exists {
+		newApplication.intervalFinishSound = newApplication.sounds["None"]
+		newApplication.cnf.InitialIntervalEndSoundName = "None"
+	}
+	newApplication.timerFinishSound, exists = newApplication.sounds[cnf.InitialTimerEndSoundName]
+	if !exists {
+		newApplication.timerFinishSound = newApplication.sounds["None"]
+	}
+
+	if cnf.VoicePackDir != "" {
+		WithVoicePack(os.DirFS(cnf.VoicePackDir))(newApplication)
+	}
+
+	for _, option := range options {
+		option(newApplication)
+	}
+
+	if newApplication.cnf.MetronomeSoundName == "" {
+		newApplication.cnf.MetronomeSoundName = "Click"
+	}
+	newApplication.metronomeSound, exists = newApplication.sounds[newApplication.cnf.MetronomeSoundName]
+	if !exists {
+		newApplication.loadError(fmt.Errorf("finding metronome sound %q", newApplication.cnf.MetronomeSoundName))
+		newApplication.metronomeSound = newApplication.sounds["None"]
+	}
+
+	if cnf.SoundPackDir != "" {
+		var errs []error
+		newApplication.soundPacks, errs = loadSoundPacks(newApplication.speakerSampleRate, cnf.SoundPackDir)
+		for _, err := range errs {
+			newApplication.loadError(err)
+		}
+	}
+	if pack := newApplication.guiDriver.Preferences().String(PREF_SOUND_PACK); pack != "" {
+		if err := newApplication.UseSoundPack(pack); err != nil {
+			newApplication.loadError(err)
+		}
+	}
+	newApplication.presets = presetLibrary{prefs: newApplication.guiDriver.Preferences()}
+	upgraded, errs := newApplication.presets.upgrade()
+	for name, report := range upgraded {
+		log.Printf("saved preset %q: %v\n", name, report)
+	}
+	for _, err := range errs {
+		newApplication.loadError(err)
+	}
+	newApplication.historyFile = cnf.HistoryFile
+	if newApplication.historyFile == "" {
+		newApplication.historyFile = filepath.Join(newApplication.guiDriver.Storage().RootURI().Path(), HISTORY_FILE)
+	}
+	if err := newApplication.upgradeHistory(); err != nil {
+		newApplication.loadError(err)
+	}
+	if last := newApplication.LastPreset(); last != "" {
+		if err := newApplication.LoadPreset(last); err != nil {
+			newApplication.loadError(fmt.Errorf("restoring last preset: %w", err))
+		}
+	}
+
+	newApplication.gui = NewGui(newApplication)
+
+	return newApplication
+}
+
+// WithAudioFiles is a functional option for configuring the sound options
+// of an application. audios is a map where the key is the display name of the
+// sound in the application and the value is the file path where it can be
+// found. Errors registering the audio files are listed to the user when the
+// application starts.
+func WithAudioFiles(audios map[string]string) func(*application) {
+	return func(a *application) {
+		for name, path := range audios {
+			if err := a.RegisterSound(name, path); err != nil {
+				a.loadError(err)
+			}
+		}
+	}
+}
+
+// WithTones is a functional option for registering synthesized sounds with
+// an application in addition to DEFAULT_TONES. tones is a map where the key
+// is the display name of the sound in the application and the value is the
+// tone to synthesize.
+func WithTones(tones map[string]Tone) func(*application) {
+	return func(a *application) {
+		for name, tone := range tones {
+			if err := a.RegisterTone(name, tone); err != nil {
+				a.loadError(err)
+			}
+		}
+	}
+}
+
+// WithVoicePack is a functional option for configuring the voice pack used
+// for spoken announcements. fsys contains the voice pack, see VOICE_MANIFEST
+// for its format. If the voice pack can't be loaded the error is listed to
+// the user when the application starts and announcements are disabled.
+func WithVoicePack(fsys fs.FS) func(*application) {
+	return func(a *application) {
+		voice, err := loadVoicePack(a.speakerSampleRate, fsys)
+		if err != nil {
+			a.loadError(fmt.Errorf("loading voice pack: %w", err))
+			return
+		}
+		a.voice = voice
+	}
+}
+
+// loadLibrary registers every sound in the sound library.
+func (a *application) loadLibrary() {
+	sounds, err := a.library.sounds()
+	if err != nil {
+		a.loadError(err)
+		return
+	}
+	for name, path := range sounds {
+		if err := a.RegisterSound(name, path); err != nil {
+			a.loadError(err)
+		}
+	}
+}
+
+// loadError logs err and records it to be listed to the user when the
+// application starts.
+func (a *application) loadError(err error) {
+	log.Printf("error %v\n", err)
+	a.loadErrors = append(a.loadErrors, err)
+}
+
+// LoadErrors returns the errors that occurred loading sounds, music and
+// voice packs while the application was created.
+func (a *application) LoadErrors() []error {
+	return a.loadErrors
+}
+
+// Run runs the application.
+func (a *application) Run() {
+	w := a.gui.simpleViewWindow()
+	a.resumeScheduledStart()
+	if len(a.loadErrors) > 0 {
+		a.gui.showLoadErrors(a.loadErrors)
+	}
+	w.ShowAndRun()
+}
+
+// OnClose handles cleanup and releases resources when an application is closed.
+func (a *application) OnClose() {
+	a.alarm.stop()
+	a.audioPlayer.StopAll()
+}
+
+// RegisterSound decodes the MP3, WAV, FLAC or Ogg Vorbis file at path and
+// registers it to the application's sound map.
+func (a *application) RegisterSound(name, path string) error {
+	stream, err := newAudioStream(a.speakerSampleRate, path)
+	if err != nil {
+		return fmt.Errorf("registering sound %q: %w", name, err)
+	}
+	a.addSound(name, stream)
+	return nil
+}
+
+// SetSoundPolicy sets the cue policy used when the sound registered under
+// name is played. Returns an error if no sound is registered under name.
+func (a *application) SetSoundPolicy(name string, policy CuePolicy) error {
+	sound, exists := a.sounds[name]
+	if !exists {
+		return fmt.Errorf("no sound registered as %q", name)
+	}
+	sound.policy = policy
+	return nil
+}
+
+// RegisterTone synthesizes tone at the speaker sample rate and registers it
+// to the application's sound map.
+func (a *application) RegisterTone(name string, tone Tone) error {
+	stream, err := newToneAudioStream(a.speakerSampleRate, tone)
+	if err != nil {
+		return fmt.Errorf("registering tone %q: %w", name, err)
+	}
+	a.addSound(name, stream)
+	return nil
+}
+
+// addSound registers stream as name with its saved volume and trim.
+func (a *application) addSound(name string, stream audioStream) {
+	stream.name = name
+	stream.volume = a.savedSoundVolume(name)
+	if err := stream.setTrim(a.savedSoundTrim(name)); err != nil {
+		a.loadError(fmt.Errorf("restoring trim of sound %q: %w", name, err))
+	}
+	a.sounds[name] = &stream
+}
+
+// MasterVolume returns the master volume between 0 and 1. The last volume
+// set is restored between runs.
+func (a *application) MasterVolume() float64 {
+	return a.guiDriver.Preferences().FloatWithFallback(PREF_MASTER_VOLUME, a.cnf.MasterVolume)
+}
+
+// SetMasterVolume sets and saves the master volume, clamped between 0 and 1.
+func (a *application) SetMasterVolume(v float64) {
+	v = clamp(v, 0, 1)
+	a.audioPlayer.SetVolume(v)
+	a.guiDriver.Preferences().SetFloat(PREF_MASTER_VOLUME, v)
+}
+
+// SoundVolume returns the gain applied to the sound registered under name.
+// Returns an error if no sound is registered under name.
+func (a *application) SoundVolume(name string) (float64, error) {
+	sound, exists := a.sounds[name]
+	if !exists {
+		return 0, fmt.Errorf("no sound registered as %q", name)
+	}
+	return sound.volume, nil
+}
+
+// SetSoundVolume sets and saves the gain applied to the sound registered
+// under name, clamped between 0 and MAX_SOUND_VOLUME. Returns an error if no
+// sound is registered under name.
+func (a *application) SetSoundVolume(name string, v float64) error {
+	sound, exists := a.sounds[name]
+	if !exists {
+		return fmt.Errorf("no sound registered as %q", name)
+	}
+	sound.volume = clamp(v, 0, MAX_SOUND_VOLUME)
+	a.guiDriver.Preferences().SetFloat(PREF_SOUND_VOLUME+name, sound.volume)
+	return nil
+}
+
+// MusicConfig returns the background music configuration. Music folders and
+// shuffle set in the application are restored between runs.
+func (a *application) MusicConfig() MusicConfig {
+	prefs := a.guiDriver.Preferences()
+	cnf := a.cnf.Music
+	cnf.WorkDir = prefs.StringWithFallback(PREF_MUSIC_WORK, cnf.WorkDir)
+	cnf.RestDir = prefs.StringWithFallback(PREF_MUSIC_REST, cnf.RestDir)
+	cnf.Shuffle = prefs.BoolWithFallback(PREF_MUSIC_SHUFFLE, cnf.Shuffle)
+	return cnf
+}
+
+// SetMusicConfig sets and saves the background music configuration and
+// loads the music folders. Returns an error if a folder can't be loaded, in
+// which case there is no music for that phase.
+func (a *application) SetMusicConfig(cnf MusicConfig) error {
+	if cnf.Volume == 0 {
+		cnf.Volume = 1
+	}
+	if cnf.DuckVolume == 0 {
+		cnf.DuckVolume = DEFAULT_DUCK_VOLUME
+	}
+	a.cnf.Music = cnf
+	prefs := a.guiDriver.Preferences()
+	prefs.SetString(PREF_MUSIC_WORK, cnf.WorkDir)
+	prefs.SetString(PREF_MUSIC_REST, cnf.RestDir)
+	prefs.SetBool(PREF_MUSIC_SHUFFLE, cnf.Shuffle)
+
+	a.audioPlayer.SetMusic(nil)
+	a.audioPlayer.SetMusicVolume(clamp(cnf.Volume, 0, 1), clamp(cnf.DuckVolume, 0, 1))
+	for phase, music := range a.music {
+		music.Close()
+		delete(a.music, phase)
+	}
+
+	var errs []error
+	dirs := map[internal.Phase]string{
+		internal.WorkPhase: cnf.WorkDir,
+		internal.RestPhase: cnf.RestDir,
+	}
+	for phase, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+		music, err := newPlaylist(a.speakerSampleRate, dir, cnf.Shuffle)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		a.music[phase] = music
+	}
+	return errors.Join(errs...)
+}
+
+// announce speaks announcement using the voice pack, if there is one.
+func (a *application) announce(announcement internal.Announcement) {
+	if a.voice == nil {
+		return
+	}
+	phrase, err := a.voice.phrase(announcementWords(announcement)...)
+	if err != nil {
+		log.Printf("error making announcement: %v\n", err)
+		return
+	}
+	a.audioPlayer.PlaySoundAt(phrase, announcement.Due, nil)
+}
+
+// playPhaseStart plays the work or rest start sound for phase, if there is
+// one.
+func (a *application) playPhaseStart(phase internal.Phase) {
+	sound := a.workStartSound
+	if phase == internal.RestPhase {
+		sound = a.restStartSound
+	}
+	if sound != nil {
+		a.audioPlayer.PlaySound(*sound, nil)
+	}
+}
+
+// playMusic plays the background music for phase, or stops the music if
+// there is none.
+func (a *application) playMusic(phase internal.Phase) {
+	music, exists := a.music[phase]
+	if !exists {
+		a.audioPlayer.SetMusic(nil)
+		return
+	}
+	a.audioPlayer.SetMusic(music)
+}
+
+// LibrarySounds returns the names of the sounds in the sound library, which
+// can be renamed and deleted.
+func (a *application) LibrarySounds() []string {
+	names, err := a.library.names()
+	if err != nil {
+		log.Printf("error listing sound library: %v\n", err)
+	}
+	return names
+}
+
+// ImportSound copies the audio file at path into the sound library and
+// registers it as name, or as the file's name without its extension if name
+// is empty. The sound is registered again each time the application starts.
+func (a *application) ImportSound(path, name string) error {
+	if name == "" {
+		name = soundName(path)
+	}
+	if _, exists := a.sounds[name]; exists {
+		return fmt.Errorf("importing %q: %w", name, ErrSoundExists)
+	}
+	// decode before copying so that the library only contains playable
+	// sounds
+	stream, err := newAudioStream(a.speakerSampleRate, path)
+	if err != nil {
+		return fmt.Errorf("importing %q: %w", name, err)
+	}
+	if _, err = a.library.add(path, name); err != nil {
+		return fmt.Errorf("importing %q: %w", name, err)
+	}
+	a.addSound(name, stream)
+	return nil
+}
+
+// RenameSound renames a sound in the sound library, keeping its volume and
+// trim.
+func (a *application) RenameSound(from, to string) error {
+	sound, exists := a.sounds[from]
+	if !exists {
+		return fmt.Errorf("no sound registered as %q", from)
+	}
+	if _, exists = a.sounds[to]; exists {
+		return fmt.Errorf("renaming %q to %q: %w", from, to, ErrSoundExists)
+	}
+	if _, err := a.library.rename(from, to); err != nil {
+		return err
+	}
+	delete(a.sounds, from)
+	sound.name = to
+	a.sounds[to] = sound
+
+	prefs := a.guiDriver.Preferences()
+	toKeys := soundPrefKeys(to)
+	for i, key := range soundPrefKeys(from) {
+		if v := prefs.FloatWithFallback(key, math.NaN()); !math.IsNaN(v) {
+			prefs.SetFloat(toKeys[i], v)
+		}
+		prefs.RemoveValue(key)
+	}
+	return nil
+}
+
+// DeleteSound deletes a sound from the sound library. Cues using the sound
+// are changed to play nothing.
+func (a *application) DeleteSound(name string) error {
+	sound, exists := a.sounds[name]
+	if !exists {
+		return fmt.Errorf("no sound registered as %q", name)
+	}
+	if err := a.library.remove(name); err != nil {
+		return err
+	}
+	delete(a.sounds, name)
+	for _, role := range append([]CueRole{BeatRole}, SOUND_PACK_ROLES...) {
+		if *a.cueSound(role) == sound {
+			a.SetCueSound(role, "None")
+		}
+	}
+	for _, key := range soundPrefKeys(name) {
+		a.guiDriver.Preferences().RemoveValue(key)
+	}
+	return nil
+}
+
+// soundPrefKeys returns the keys of the preferences saved for the sound
+// called name.
+func soundPrefKeys(name string) []string {
+	return []string{
+		PREF_SOUND_VOLUME + name,
+		PREF_SOUND_TRIM + name + ".start",
+		PREF_SOUND_TRIM + name + ".end",
+		PREF_SOUND_TRIM + name + ".fadein",
+		PREF_SOUND_TRIM + name + ".fadeout",
+	}
+}
+
+// SoundPacks returns the names of the sound packs that loaded from
+// Config.SoundPackDir.
+func (a *application) SoundPacks() []string {
+	names := make([]string, len(a.soundPacks))
+	for i, pack := range a.soundPacks {
+		names[i] = pack.name
+	}
+	return names
+}
+
+// SoundPack returns the name of the sound pack in use, or an empty string if
+// there isn't one.
+func (a *application) SoundPack() string {
+	return a.soundPack
+}
+
+// UseSoundPack registers the sounds of the sound pack called name and plays
+// them for their roles. Roles in SOUND_PACK_ROLES that the pack has no sound
+// for play nothing. The pack is used again the next time the application
+// starts.
+func (a *application) UseSoundPack(name string) error {
+	var pack *soundPack
+	for _, p := range a.soundPacks {
+		if p.name == name {
+			pack = p
+		}
+	}
+	if pack == nil {
+		return fmt.Errorf("no sound pack called %q", name)
+	}
+
+	for _, role := range SOUND_PACK_ROLES {
+		stream, exists := pack.sounds[role]
+		if !exists {
+			a.SetCueSound(role, "None")
+			continue
+		}
+		a.addSound(pack.registeredName(role), stream)
+		a.SetCueSound(role, pack.registeredName(role))
+	}
+	a.soundPack = name
+	a.guiDriver.Preferences().SetString(PREF_SOUND_PACK, name)
+	return nil
+}
+
+// CueSound returns the name of the sound played for role.
+func (a *application) CueSound(role CueRole) string {
+	cue := a.cueSound(role)
+	if cue == nil || *cue == nil {
+		return "None"
+	}
+	return (*cue).name
+}
+
+// SetCueSound plays the sound registered as name for role.
+func (a *application) SetCueSound(role CueRole, name string) error {
+	cue := a.cueSound(role)
+	if cue == nil {
+		return fmt.Errorf("unknown cue role %q", role)
+	}
+	sound, exists := a.sounds[name]
+	if !exists {
+		return fmt.Errorf("no sound registered as %q", name)
+	}
+	switch role {
+	case WorkStartRole, RestStartRole, CountdownRole:
+		if name == "None" {
+			sound = nil
+		}
+	}
+	*cue = sound
+	return nil
+}
+
+// cueSound returns the field holding the sound played for role, or nil if
+// role is unknown.
+func (a *application) cueSound(role CueRole) **audioStream {
+	switch role {
+	case WorkStartRole:
+		return &a.workStartSound
+	case RestStartRole:
+		return &a.restStartSound
+	case CountdownRole:
+		return &a.countdownSound
+	case IntervalEndRole:
+		return &a.intervalFinishSound
+	case FinishRole:
+		return &a.timerFinishSound
+	case BeatRole:
+		return &a.metronomeSound
+	}
+	return nil
+}
+
+// SoundTrim returns the trim of the sound registered as name.
+func (a *application) SoundTrim(name string) (SoundTrim, error) {
+	sound, exists := a.sounds[name]
+	if !exists {
+		return SoundTrim{}, fmt.Errorf("no sound registered as %q", name)
+	}
+	return sound.trim(), nil
+}
+
+// SetSoundTrim trims the sound registered as name and saves the trim so it
+// is restored the next time the sound is registered.
+func (a *application) SetSoundTrim(name string, trim SoundTrim) error {
+	sound, exists := a.sounds[name]
+	if !exists {
+		return fmt.Errorf("no sound registered as %q", name)
+	}
+	if err := sound.setTrim(trim); err != nil {
+		return fmt.Errorf("trimming sound %q: %w", name, err)
+	}
+	prefs := a.guiDriver.Preferences()
+	prefs.SetFloat(PREF_SOUND_TRIM+name+".start", trim.Start.Seconds())
+	prefs.SetFloat(PREF_SOUND_TRIM+name+".end", trim.End.Seconds())
+	prefs.SetFloat(PREF_SOUND_TRIM+name+".fadein", trim.FadeIn.Seconds())
+	prefs.SetFloat(PREF_SOUND_TRIM+name+".fadeout", trim.FadeOut.Seconds())
+	return nil
+}
+
+// PreviewSound plays the sound registered as name with its trim and volume.
+func (a *application) PreviewSound(name string) error {
+	sound, exists := a.sounds[name]
+	if !exists {
+		return fmt.Errorf("no sound registered as %q", name)
+	}
+	a.previewSound(*sound)
+	return nil
+}
+
+// previewSound plays stream alongside any other sounds, ignoring its cue
+// policy.
+func (a *application) previewSound(stream audioStream) {
+	stream.policy = CuePolicy{}
+	a.audioPlayer.PlaySound(stream, nil)
+}
+
+func (a *application) savedSoundTrim(name string) SoundTrim {
+	prefs := a.guiDriver.Preferences()
+	seconds := func(key string) time.Duration {
+		return time.Duration(prefs.Float(PREF_SOUND_TRIM+name+key) * float64(time.Second))
+	}
+	return SoundTrim{
+		Start:   seconds(".start"),
+		End:     seconds(".end"),
+		FadeIn:  seconds(".fadein"),
+		FadeOut: seconds(".fadeout"),
+	}
+}
+
+// savedSoundVolume returns the saved gain of the sound registered under
+// name, falling back to the configured gain or 1.
+func (a *application) savedSoundVolume(name string) float64 {
+	fallback, exists := a.cnf.SoundVolumes[name]
+	if !exists {
+		fallback = 1
+	}
+	return clamp(a.guiDriver.Preferences().FloatWithFallback(PREF_SOUND_VOLUME+name, fallback), 0, MAX_SOUND_VOLUME)
+}
+
+// runTimer starts the timer running the session if presets are queued in
+// it, or else the program.
+func (a *application) runTimer() error {
+	a.timerConfig.CueLead = a.audioPlayer.Latency() + CUE_LEAD_MARGIN
+	cnf := *a.timerConfig
+	if a.countdownSound != nil && cnf.CountdownFrom == 0 {
+		cnf.CountdownFrom = ANNOUNCE_COUNTDOWN_FROM
+	}
+	a.seed = time.Now().UnixNano()
+	if a.replaySeed != nil {
+		a.seed, a.replaySeed = *a.replaySeed, nil
+	}
+	cnf.Seed = a.seed
+	if a.program != nil {
+		cnf.Segments = programSegments(*a.program, a.seed)
+	}
+	if a.program != nil && a.program.Random() {
+		log.Printf("running randomized program with seed %d\n", a.seed)
+	}
+	if session := a.Session(); len(session) > 0 {
+		segments, err := a.presets.sessionSegments(session, a.seed)
+		if err != nil {
+			return err
+		}
+		cnf.Segments = segments
+	}
+	a.timer = internal.NewRepeatCountdownTimer(cnf)
+	done, shown := make(chan struct{}), make(chan struct{})
+
+	// show interval names, time remaining and tracks running alongside the
+	// program as they change
+	go func() {
+		defer close(shown)
+		for {
+			select {
+			case name := <-a.timer.IntervalName():
+				a.gui.updateTimerName(name)
+			case update := <-a.timer.TimeRemaining():
+				a.gui.updateTimerDisplay(update)
+				a.gui.updateProgress(a.timer.Progress())
+			case update := <-a.timer.Tracks():
+				a.gui.updateTrack(update)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	a.running.Store(true)
+	a.runs.Add(1)
+	started := time.Now()
+	go func() {
+		defer a.runs.Done()
+		a.startTimerWithCues(a.timer)
+		a.recordRun(started, a.timer)
+		close(done)
+		<-shown
+		if cnf.Repeat {
+			summary := a.timer.Summary()
+			log.Printf("run summary: %v\n", summary)
+			a.gui.showSummary(summary)
+		}
+		a.gui.reset()
+		a.running.Store(false)
+	}()
+	return nil
+}
+
+// SetRepeat makes the timer repeat its program each time it finishes until
+// it is stopped or, if until isn't zero, until that time. A summary of what
+// was done is shown when a repeating run ends.
+func (a *application) SetRepeat(repeat bool, until time.Time) {
+	a.timerConfig.Repeat = repeat
+	a.timerConfig.Until = until
+}
+
+// startTimerWithCues starts t, playing the work or rest start sound and
+// switching background music as each phase starts, the interval finish sound
+// at the end of each interval, the countdown sound or spoken countdown, the
+// metronome sound on each beat, announcements and the timer finish sound
+// once the timer finishes. Interval, beat and announcement cues are scheduled so
+// they are heard on time, as long as t has a cue lead of at least the
+// player's latency. Blocks until the timer finishes.
+func (a *application) startTimerWithCues(t *internal.RepeatTimer) {
+	finished := make(chan bool)
+	cuesDone := make(chan bool)
+
+	go func() {
+		defer close(cuesDone)
+		for {
+			select {
+			case end := <-t.IntervalEnding():
+				a.audioPlayer.PlaySoundAt(*a.intervalFinishSound, end, nil)
+			case beat := <-t.Beat():
+				a.audioPlayer.PlaySoundAt(*a.metronomeSound, beat, nil)
+			case announcement := <-t.Announcements():
+				if announcement.Kind == internal.CountdownAnnouncement && a.countdownSound != nil {
+					a.audioPlayer.PlaySoundAt(*a.countdownSound, announcement.Due, nil)
+					continue
+				}
+				a.announce(announcement)
+			case phase := <-t.PhaseStarted():
+				a.playMusic(phase)
+				a.playPhaseStart(phase)
+			case <-finished:
+				// play any interval finished cues that arrived with the end
+				// of the timer
+				for {
+					select {
+					case end := <-t.IntervalEnding():
+						a.audioPlayer.PlaySoundAt(*a.intervalFinishSound, end, nil)
+					default:
+						return
+					}
+				}
+			}
+		}
+	}()
+
+	t.Start()
+	close(finished)
+	<-cuesDone
+	a.audioPlayer.SetMusic(nil)
+	if a.cnf.Alarm.Enabled && !t.Cancelled() {
+		a.soundAlarm()
+		return
+	}
+	a.audioPlayer.PlaySound(*a.timerFinishSound, nil)
+}
+
+// AlarmConfig returns the application's alarm configuration.
+func (a *application) AlarmConfig() AlarmConfig {
+	return a.cnf.Alarm
+}
+
+// SetAlarmConfig sets the application's alarm configuration, filling in
+// defaults for any durations that aren't set. It takes effect the next time
+// the timer finishes.
+func (a *application) SetAlarmConfig(cnf AlarmConfig) {
+	if cnf.Snooze <= 0 {
+		cnf.Snooze = DEFAULT_SNOOZE
+	}
+	if cnf.MaxDuration <= 0 {
+		cnf.MaxDuration = DEFAULT_ALARM_MAX
+	}
+	a.cnf.Alarm = cnf
+}
+
+// AlarmRinging returns whether the alarm is sounding.
+func (a *application) AlarmRinging() bool {
+	return a.alarm.ringing()
+}
+
+// DismissAlarm stops the alarm, whether it is sounding or snoozed. Returns
+// false if it was doing neither.
+func (a *application) DismissAlarm() bool {
+	if !a.alarm.stop() {
+		return false
+	}
+	if a.gui != nil {
+		a.gui.hideAlarm()
+		a.gui.reset()
+	}
+	return true
+}
+
+// SnoozeAlarm silences the alarm and starts a countdown of the configured
+// snooze length, after which the alarm sounds again. Returns false if the
+// alarm isn't sounding.
+func (a *application) SnoozeAlarm() bool {
+	snoozed := a.alarm.snooze(a.cnf.Alarm.Snooze, func(remaining time.Duration) {
+		if a.gui != nil {
+			a.gui.updateSnooze(remaining)
+		}
+	}, a.soundAlarm)
+	if snoozed && a.gui != nil {
+		a.gui.hideAlarm()
+	}
+	return snoozed
+}
+
+// soundAlarm loops the timer finish sound until the alarm is dismissed or
+// snoozed, or has sounded for the configured maximum.
+func (a *application) soundAlarm() {
+	a.alarm.ring(*a.timerFinishSound, a.cnf.Alarm.MaxDuration, func() {
+		if a.gui != nil {
+			a.gui.hideAlarm()
+			a.gui.reset()
+		}
+	})
+	if a.gui != nil {
+		a.gui.showAlarm()
+	}
+}
+
+func (a *application) handleTimerCancel() {
+	a.timer.Cancel()
+}
+
+func (a *application) handleTimerPause() {
+	a.timer.Pause()
+	a.audioPlayer.PauseMusic(true)
+}
+
+func (a *application) handleTimerResume() {
+	a.timer.Resume()
+	a.audioPlayer.PauseMusic(false)
+}
+
+func (a *application) handleTimerSkip() {
+	a.timer.Skip()
+}
+
+func (a *application) soundOptions() []string {
+	opts := []string{}
+	for optionKey := range a.sounds {
+		opts = append(opts, optionKey)
+	}
+	return opts
+}
+
+// newGuiDriver returns a new fyne app. Preferences can only be persisted if
+// the app has a unique ID.
+func newGuiDriver(id string) fyne.App {
+	if id == "" {
+		return app.New()
+	}
+	return app.NewWithID(id)
+}
+
+func clamp(v, min, max float64) float64 {
+	switch {
+	case v < min:
+		return min
+	case v > max:
+		return max
+	default:
+		return v
+	}
+}
+
+func genIncrementingDigitStringSlice(start, size int) []string {
+	s := []string{}
+	for i := start; len(s) <= size; i++ {
+		s = append(s, strconv.Itoa(i))
+	}
+	return s
+}
+
+func genDigitStringToIntMap(max int) map[string]int {
+	m := map[string]int{}
+	for i := 0; i <= max; i++ {
+		m[strconv.Itoa(i)] = i
+	}
+	return m
+}
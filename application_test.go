@@ -0,0 +1,251 @@
+package timer
+
+import (
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/faiface/beep"
+	"github.com/gabriel-ross/timer-go/internal"
+	"github.com/stretchr/testify/assert"
+)
+
+// playedSound is a sound played through a recordingPlayer.
+type playedSound struct {
+	name string
+	at   time.Duration
+}
+
+// recordingPlayer is a fake player that records which sounds are played and
+// the engine time, as given by clock, at which they were played.
+type recordingPlayer struct {
+	mu     sync.Mutex
+	clock  func() time.Duration
+	played []playedSound
+	volume float64
+}
+
+func (p *recordingPlayer) PlaySound(stream audioStream, done chan<- bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var at time.Duration
+	if p.clock != nil {
+		at = p.clock()
+	}
+	p.played = append(p.played, playedSound{name: stream.name, at: at})
+	if done != nil {
+		go func() {
+			done <- true
+		}()
+	}
+}
+
+// PlaySoundAt records the engine time at which the sound would be heard.
+func (p *recordingPlayer) PlaySoundAt(stream audioStream, at time.Time, done chan<- bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var heard time.Duration
+	if p.clock != nil {
+		heard = p.clock() + time.Until(at)
+	}
+	p.played = append(p.played, playedSound{name: stream.name, at: heard})
+	if done != nil {
+		go func() {
+			done <- true
+		}()
+	}
+}
+
+func (p *recordingPlayer) Latency() time.Duration {
+	return 0
+}
+
+func (p *recordingPlayer) StopAll() {}
+
+func (p *recordingPlayer) SetVolume(v float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.volume = v
+}
+
+func (p *recordingPlayer) SetMusic(music beep.Streamer) {}
+
+func (p *recordingPlayer) PauseMusic(paused bool) {}
+
+func (p *recordingPlayer) SetMusicVolume(volume, duckVolume float64) {}
+
+func (p *recordingPlayer) sounds() []playedSound {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]playedSound{}, p.played...)
+}
+
+func TestStartTimerWithCues(t *testing.T) {
+	player := &recordingPlayer{}
+	a := &application{
+		audioPlayer:         player,
+		intervalFinishSound: &audioStream{name: "Ding"},
+		timerFinishSound:    &audioStream{name: "Chime"},
+	}
+	timer := internal.NewRepeatCountdownTimer(internal.Config{
+		Intervals:       2,
+		IntervalSeconds: 1,
+		RestSeconds:     1,
+		CueLead:         200 * time.Millisecond,
+	})
+	player.clock = timer.Elapsed
+
+	a.startTimerWithCues(timer)
+
+	played := player.sounds()
+	expected := []playedSound{
+		{"Ding", time.Second},
+		{"Ding", 2 * time.Second},
+		{"Ding", 3 * time.Second},
+		{"Chime", 3 * time.Second},
+	}
+	if assert.Len(t, played, len(expected)) {
+		for i, sound := range played {
+			assert.Equal(t, expected[i].name, sound.name)
+			assert.InDelta(t, expected[i].at, sound.at, float64(PRECISION))
+		}
+	}
+}
+
+// PRECISION is the allowed difference between expected and actual engine
+// times.
+var PRECISION = 300 * time.Millisecond
+
+func TestStartTimerWithMetronome(t *testing.T) {
+	player := &recordingPlayer{}
+	a := &application{
+		audioPlayer:         player,
+		intervalFinishSound: &audioStream{name: "Ding"},
+		timerFinishSound:    &audioStream{name: "Chime"},
+		metronomeSound:      &audioStream{name: "Click"},
+	}
+	timer := internal.NewRepeatCountdownTimer(internal.Config{
+		Intervals:       1,
+		IntervalSeconds: 1,
+		CueLead:         200 * time.Millisecond,
+		WorkMetronome:   internal.Metronome{StartBPM: 120},
+	})
+	player.clock = timer.Elapsed
+
+	a.startTimerWithCues(timer)
+
+	played := player.sounds()
+	expected := []playedSound{
+		{"Click", 0},
+		{"Click", 500 * time.Millisecond},
+		{"Ding", time.Second},
+		{"Chime", time.Second},
+	}
+	if assert.Len(t, played, len(expected)) {
+		for i, sound := range played {
+			assert.Equal(t, expected[i].name, sound.name)
+			assert.InDelta(t, expected[i].at, sound.at, float64(PRECISION))
+		}
+	}
+}
+
+func TestStartTimerWithAlarm(t *testing.T) {
+	player := &recordingPlayer{}
+	a := &application{
+		audioPlayer:         player,
+		intervalFinishSound: &audioStream{name: "Ding"},
+		timerFinishSound:    &audioStream{name: "Chime"},
+		alarm:               newAlarm(player),
+	}
+	a.alarm.gap = 10 * time.Millisecond
+	a.SetAlarmConfig(AlarmConfig{Enabled: true})
+	timer := internal.NewRepeatCountdownTimer(internal.Config{
+		Intervals:       1,
+		IntervalSeconds: 1,
+	})
+
+	a.startTimerWithCues(timer)
+	assert.True(t, a.AlarmRinging())
+	assert.Eventually(t, func() bool { return len(player.sounds()) >= 4 }, time.Second, time.Millisecond)
+	assert.True(t, a.DismissAlarm())
+	assert.False(t, a.AlarmRinging())
+	assert.False(t, a.DismissAlarm())
+
+	// a cancelled timer doesn't sound the alarm
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		timer.Cancel()
+	}()
+	a.startTimerWithCues(timer)
+	assert.False(t, a.AlarmRinging())
+}
+
+func TestStartTimerWithTimeCap(t *testing.T) {
+	player := &recordingPlayer{}
+	a := &application{
+		audioPlayer:         player,
+		intervalFinishSound: &audioStream{name: "Ding"},
+		timerFinishSound:    &audioStream{name: "Chime"},
+		alarm:               newAlarm(player),
+	}
+	a.alarm.gap = 10 * time.Millisecond
+	a.SetAlarmConfig(AlarmConfig{Enabled: true})
+	timer := internal.NewRepeatCountdownTimer(internal.Config{
+		Intervals:       5,
+		IntervalSeconds: 1,
+		Tracks: []internal.Track{{
+			Name:        "Time cap",
+			Segments:    []internal.Segment{{Phase: internal.WorkPhase, Duration: 2 * time.Second}},
+			EndsProgram: true,
+		}},
+	})
+
+	// reaching the cap finishes the program rather than cancelling it, so
+	// the alarm sounds
+	startTime := time.Now()
+	a.startTimerWithCues(timer)
+	assert.InDelta(t, 2*time.Second, time.Since(startTime), float64(300*time.Millisecond))
+	assert.Equal(t, 0, timer.EndedBy())
+	assert.True(t, a.AlarmRinging())
+	assert.True(t, a.DismissAlarm())
+}
+
+func TestStartTimerWithRoleSounds(t *testing.T) {
+	player := &recordingPlayer{}
+	a := &application{
+		audioPlayer:         player,
+		intervalFinishSound: &audioStream{name: "Ding"},
+		timerFinishSound:    &audioStream{name: "Chime"},
+		workStartSound:      &audioStream{name: "Gong"},
+		countdownSound:      &audioStream{name: "Tick"},
+	}
+	timer := internal.NewRepeatCountdownTimer(internal.Config{
+		Intervals:       1,
+		IntervalSeconds: 3,
+		RestSeconds:     1,
+		RestBeforeStart: true,
+		CountdownFrom:   2,
+		CueLead:         200 * time.Millisecond,
+	})
+	player.clock = timer.Elapsed
+
+	a.startTimerWithCues(timer)
+
+	played := player.sounds()
+	sort.SliceStable(played, func(i, j int) bool { return played[i].at < played[j].at })
+	expected := []playedSound{
+		{"Ding", time.Second},
+		{"Gong", time.Second},
+		{"Tick", 2 * time.Second},
+		{"Tick", 3 * time.Second},
+		{"Ding", 4 * time.Second},
+		{"Chime", 4 * time.Second},
+	}
+	if assert.Len(t, played, len(expected)) {
+		for i, sound := range played {
+			assert.Equal(t, expected[i].name, sound.name)
+			assert.InDelta(t, expected[i].at, sound.at, float64(PRECISION))
+		}
+	}
+}
@@ -1,98 +1,270 @@
-package timer
-
-import (
-	"os"
-	"time"
-
-	"github.com/faiface/beep"
-	"github.com/faiface/beep/mp3"
-	"github.com/faiface/beep/speaker"
-)
-
-type audioStream struct {
-	stream   beep.StreamSeekCloser
-	format   beep.Format
-	startPos int
-}
-
-type player struct{}
-
-// NewPlayer initializes the speaker with a sample rate of sr,
-// and a buffer size of sr/buffRatio. Returns an error if there
-// is an error initializing the speaker.
-func NewPlayer(sr beep.SampleRate, buffRatio int) (player, error) {
-	err := speaker.Init(sr, sr.N(time.Second)/buffRatio)
-	if err != nil {
-		return player{}, err
-	}
-	return player{}, nil
-}
-
-// playSound resamples the audio stream, stream, and plays it through the
-// speaker. It takes an optional done channel on which a signal will be sent
-// when the audio finishes playing.
-func (p player) PlaySound(speakerSampleRate beep.SampleRate, stream audioStream, done chan<- bool) {
-	speaker.Clear()
-	stream.stream.Seek(stream.startPos)
-	speaker.Play(beep.Seq(beep.Resample(3, stream.format.SampleRate, speakerSampleRate, stream.stream), beep.Callback(func() {
-		if done != nil {
-			done <- true
-		}
-	})))
-}
-
-// newAudioStream decodes an mp3 file at the given path and returns a new
-// audioStream.
-func (p player) NewAudioStream(filePath string) (audioStream, error) {
-	f, err := os.Open(filePath)
-	if err != nil {
-		return audioStream{}, err
-	}
-	streamer, format, err := mp3.Decode(f)
-	if err != nil {
-		return audioStream{}, err
-	}
-	return audioStream{
-		stream:   streamer,
-		format:   format,
-		startPos: streamer.Position(),
-	}, nil
-}
-
-func (p player) NilAudioStream(sr beep.SampleRate) audioStream {
-	return audioStream{
-		stream: nilStream{},
-		format: beep.Format{
-			SampleRate:  sr,
-			NumChannels: 2,
-			Precision:   3,
-		},
-		startPos: 0,
-	}
-}
-
-type nilStream struct{}
-
-func (ns nilStream) Stream(samples [][2]float64) (n int, ok bool) {
-	return 0, true
-}
-
-func (ns nilStream) Err() error {
-	return nil
-}
-
-func (ns nilStream) Len() int {
-	return 0
-}
-
-func (ns nilStream) Position() int {
-	return 0
-}
-
-func (ns nilStream) Seek(p int) error {
-	return nil
-}
-
-func (ns nilStream) Close() error {
-	return nil
-}
+package timer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/faiface/beep"
+	"github.com/faiface/beep/effects"
+	"github.com/faiface/beep/flac"
+	"github.com/faiface/beep/mp3"
+	"github.com/faiface/beep/vorbis"
+	"github.com/faiface/beep/wav"
+)
+
+// ErrUnsupportedAudioFormat is returned when an audio file is not in one of
+// the formats the player can decode.
+var ErrUnsupportedAudioFormat = errors.New("unsupported audio format")
+
+// audioFormat is an encoded audio file format the player can decode.
+type audioFormat int
+
+const (
+	formatUnknown audioFormat = iota
+	formatMP3
+	formatWAV
+	formatFLAC
+	formatOGG
+)
+
+func (f audioFormat) String() string {
+	switch f {
+	case formatMP3:
+		return "mp3"
+	case formatWAV:
+		return "wav"
+	case formatFLAC:
+		return "flac"
+	case formatOGG:
+		return "ogg"
+	default:
+		return "unknown"
+	}
+}
+
+// audioStream is a sound decoded into memory at the speaker sample rate.
+// Each playback streams from its own position in the buffer, so a sound can
+// be played any number of times concurrently.
+type audioStream struct {
+	name     string // Name the sound is registered under
+	buffer   *beep.Buffer
+	format   beep.Format
+	startPos int
+	endPos   int // Position at which playback ends, zero plays to the end
+	fadeIn   int // Samples taken to fade in from startPos
+	fadeOut  int // Samples taken to fade out before the end
+	policy   CuePolicy
+	volume   float64 // Linear gain applied to the sound, 1 is unchanged
+}
+
+// streamer returns a new streamer over the sound with its trim, fades and
+// volume applied.
+func (s audioStream) streamer() beep.Streamer {
+	end := s.endPos
+	if end == 0 {
+		end = s.buffer.Len()
+	}
+	var streamer beep.Streamer = s.buffer.Streamer(s.startPos, end)
+	if s.fadeIn > 0 || s.fadeOut > 0 {
+		streamer = &fader{
+			streamer: streamer,
+			len:      end - s.startPos,
+			fadeIn:   s.fadeIn,
+			fadeOut:  s.fadeOut,
+		}
+	}
+	if s.volume == 1 {
+		return streamer
+	}
+	return newVolume(streamer, s.volume)
+}
+
+// newAudioStream decodes an MP3, WAV, FLAC or Ogg Vorbis file at the given
+// path into memory, resampled to the sample rate sr, and returns a new
+// audioStream. The format is detected from the file's magic bytes, falling
+// back to its extension. Returns an error wrapping ErrUnsupportedAudioFormat
+// if the format can't be determined.
+func newAudioStream(sr beep.SampleRate, filePath string) (audioStream, error) {
+	streamer, format, err := decodeFile(filePath)
+	if err != nil {
+		return audioStream{}, err
+	}
+	defer streamer.Close()
+
+	stream := bufferStream(sr, beep.Resample(3, format.SampleRate, sr, streamer))
+	if err = streamer.Err(); err != nil {
+		return audioStream{}, fmt.Errorf("decoding %s: %w", filePath, err)
+	}
+	return stream, nil
+}
+
+// newFSAudioStream decodes the audio file at path in fsys into memory,
+// resampled to the sample rate sr, and returns a new audioStream.
+func newFSAudioStream(sr beep.SampleRate, fsys fs.FS, path string) (audioStream, error) {
+	data, err := fs.ReadFile(fsys, path)
+	if err != nil {
+		return audioStream{}, err
+	}
+	streamer, format, err := decodeReader(path, readSeekNopCloser{bytes.NewReader(data)})
+	if err != nil {
+		return audioStream{}, err
+	}
+	defer streamer.Close()
+
+	stream := bufferStream(sr, beep.Resample(3, format.SampleRate, sr, streamer))
+	if err = streamer.Err(); err != nil {
+		return audioStream{}, fmt.Errorf("decoding %s: %w", path, err)
+	}
+	return stream, nil
+}
+
+// decodeFile opens the MP3, WAV, FLAC or Ogg Vorbis file at the given path
+// and returns a streamer decoding it. Closing the streamer closes the file.
+func decodeFile(filePath string) (beep.StreamSeekCloser, beep.Format, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, beep.Format{}, err
+	}
+	return decodeReader(filePath, f)
+}
+
+// decodeReader returns a streamer decoding the MP3, WAV, FLAC or Ogg Vorbis
+// audio in r. The format is detected from the audio's magic bytes, falling
+// back to the extension of name. Closing the streamer closes r, and r is
+// closed if there is an error.
+func decodeReader(name string, r io.ReadSeekCloser) (beep.StreamSeekCloser, beep.Format, error) {
+	header := make([]byte, 12)
+	n, err := io.ReadFull(r, header)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		r.Close()
+		return nil, beep.Format{}, fmt.Errorf("reading %s: %w", name, err)
+	}
+	if _, err = r.Seek(0, io.SeekStart); err != nil {
+		r.Close()
+		return nil, beep.Format{}, fmt.Errorf("reading %s: %w", name, err)
+	}
+
+	var (
+		streamer beep.StreamSeekCloser
+		format   beep.Format
+	)
+	detected := detectAudioFormat(name, header[:n])
+	switch detected {
+	case formatMP3:
+		streamer, format, err = mp3.Decode(r)
+	case formatWAV:
+		streamer, format, err = wav.Decode(r)
+	case formatFLAC:
+		streamer, format, err = flac.Decode(r)
+	case formatOGG:
+		streamer, format, err = vorbis.Decode(r)
+	default:
+		r.Close()
+		return nil, beep.Format{}, fmt.Errorf("%s: %w", name, ErrUnsupportedAudioFormat)
+	}
+	if err != nil {
+		r.Close()
+		return nil, beep.Format{}, fmt.Errorf("decoding %s as %s: %w", name, detected, err)
+	}
+	return streamer, format, nil
+}
+
+// detectAudioFormat determines the format of an audio file from the first
+// bytes of its contents, header. If the header is not recognised the file
+// extension of path is used instead.
+func detectAudioFormat(path string, header []byte) audioFormat {
+	switch {
+	case len(header) >= 12 && bytes.Equal(header[:4], []byte("RIFF")) && bytes.Equal(header[8:12], []byte("WAVE")):
+		return formatWAV
+	case bytes.HasPrefix(header, []byte("fLaC")):
+		return formatFLAC
+	case bytes.HasPrefix(header, []byte("OggS")):
+		return formatOGG
+	case bytes.HasPrefix(header, []byte("ID3")):
+		return formatMP3
+	case len(header) >= 2 && header[0] == 0xFF && header[1]&0xE0 == 0xE0: // MPEG frame sync
+		return formatMP3
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".mp3":
+		return formatMP3
+	case ".wav", ".wave":
+		return formatWAV
+	case ".flac":
+		return formatFLAC
+	case ".ogg", ".oga":
+		return formatOGG
+	default:
+		return formatUnknown
+	}
+}
+
+// newToneAudioStream synthesizes tone at the sample rate sr into memory and
+// returns a new audioStream. Returns an error if the tone is invalid.
+func newToneAudioStream(sr beep.SampleRate, tone Tone) (audioStream, error) {
+	stream, err := newToneStream(sr, tone)
+	if err != nil {
+		return audioStream{}, err
+	}
+	return bufferStream(sr, stream), nil
+}
+
+// nilAudioStream returns an audioStream containing no audio.
+func nilAudioStream(sr beep.SampleRate) audioStream {
+	return bufferStream(sr, beep.Silence(0))
+}
+
+// bufferStream reads all of s, which must be at the sample rate sr, into a
+// new audioStream.
+func bufferStream(sr beep.SampleRate, s beep.Streamer) audioStream {
+	format := beep.Format{
+		SampleRate:  sr,
+		NumChannels: 2,
+		Precision:   2,
+	}
+	buffer := beep.NewBuffer(format)
+	buffer.Append(s)
+	return audioStream{
+		buffer:   buffer,
+		format:   format,
+		startPos: 0,
+		volume:   1,
+	}
+}
+
+// newVolume returns an effect scaling s by the linear gain v.
+func newVolume(s beep.Streamer, v float64) *effects.Volume {
+	volume := &effects.Volume{
+		Streamer: s,
+		Base:     2,
+	}
+	setVolume(volume, v)
+	return volume
+}
+
+// setVolume sets the volume effect to scale by the linear gain v. beep
+// volumes are logarithmic so v is converted to a power of the base.
+func setVolume(volume *effects.Volume, v float64) {
+	volume.Silent = v <= 0
+	if !volume.Silent {
+		volume.Volume = math.Log2(v)
+	}
+}
+
+// readSeekNopCloser is an io.ReadSeeker with a Close method that does
+// nothing.
+type readSeekNopCloser struct {
+	io.ReadSeeker
+}
+
+func (readSeekNopCloser) Close() error {
+	return nil
+}
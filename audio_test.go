@@ -0,0 +1,31 @@
+package timer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectAudioFormat(t *testing.T) {
+	tests := []struct {
+		name   string
+		path   string
+		header []byte
+		want   audioFormat
+	}{
+		{"wav magic", "cue", []byte("RIFF\x24\x08\x00\x00WAVE"), formatWAV},
+		{"flac magic", "cue", []byte("fLaC\x00\x00\x00\x22"), formatFLAC},
+		{"ogg magic", "cue", []byte("OggS\x00\x02"), formatOGG},
+		{"mp3 id3 tag", "cue", []byte("ID3\x04\x00"), formatMP3},
+		{"mp3 frame sync", "cue", []byte{0xFF, 0xFB, 0x90, 0x64}, formatMP3},
+		{"magic wins over extension", "cue.mp3", []byte("OggS\x00\x02"), formatOGG},
+		{"extension fallback", "cue.WAV", []byte{}, formatWAV},
+		{"unknown", "cue.txt", []byte("hello world!"), formatUnknown},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, detectAudioFormat(tc.path, tc.header))
+		})
+	}
+}
@@ -0,0 +1,111 @@
+// Command preset works with timer preset and history files.
+//
+// Usage:
+//
+//	preset migrate [-n] FILE...
+//	preset plan -budget DURATION -ratio WORK:REST -stations N [-rounds N] [-warmup DURATION] [-cooldown DURATION] [-o FILE]
+//
+// migrate upgrades preset and history files written with older versions of
+// their schema to the current version. With -n the files are checked and the
+// migrations that would be applied are reported without rewriting them.
+//
+// plan generates a program that fits exactly in a time budget and prints it
+// in the interval notation with the adjustments made to fit it in whole
+// seconds. With -o the program is also saved as a preset file.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/gabriel-ross/timer-go/preset"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	switch os.Args[1] {
+	case "migrate":
+		os.Exit(migrate(os.Args[2:]))
+	case "plan":
+		os.Exit(plan(os.Args[2:]))
+	default:
+		usage()
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: preset migrate [-n] FILE...")
+	fmt.Fprintln(os.Stderr, "       preset plan -budget DURATION -ratio WORK:REST -stations N [-rounds N] [-warmup DURATION] [-cooldown DURATION] [-o FILE]")
+	os.Exit(2)
+}
+
+func migrate(args []string) int {
+	flags := flag.NewFlagSet("migrate", flag.ExitOnError)
+	dryRun := flags.Bool("n", false, "report the migrations without rewriting the files")
+	flags.Parse(args)
+	if flags.NArg() == 0 {
+		usage()
+	}
+	status := 0
+	for _, path := range flags.Args() {
+		report, err := preset.MigrateFile(path, *dryRun)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			status = 1
+			continue
+		}
+		if *dryRun {
+			fmt.Printf("%s (dry run): %v\n", path, report)
+		} else {
+			fmt.Printf("%s: %v\n", path, report)
+		}
+	}
+	return status
+}
+
+func plan(args []string) int {
+	flags := flag.NewFlagSet("plan", flag.ExitOnError)
+	budget := flags.Duration("budget", 0, "total length of the program, such as 20m")
+	ratio := flags.String("ratio", "1:1", "work to rest ratio, such as 3:1")
+	stations := flags.Int("stations", 0, "number of stations")
+	rounds := flags.Int("rounds", 1, "number of times each station is worked")
+	warmUp := flags.Duration("warmup", 0, "length of the warm up")
+	coolDown := flags.Duration("cooldown", 0, "length of the cool down")
+	output := flags.String("o", "", "save the program as a preset `file`")
+	flags.Parse(args)
+
+	p := preset.Plan{
+		Budget:   preset.Duration(*budget),
+		Stations: *stations,
+		Rounds:   *rounds,
+		WarmUp:   preset.Duration(*warmUp),
+		CoolDown: preset.Duration(*coolDown),
+	}
+	var err error
+	if p.WorkRatio, p.RestRatio, err = preset.ParseRatio(*ratio); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	result, err := p.Generate()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	fmt.Println(result.Program)
+	fmt.Printf("work %s, rest %s\n", result.Work, result.Rest)
+	for _, adjustment := range result.Adjustments {
+		fmt.Println(adjustment)
+	}
+	if *output != "" {
+		saved := preset.New(fmt.Sprintf("%s plan", *budget))
+		saved.Program = result.Program
+		if err = saved.SaveFile(*output); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return 1
+		}
+	}
+	return 0
+}
@@ -1,206 +1,1835 @@
-package timer
-
-import (
-	"image/color"
-
-	"fyne.io/fyne/v2"
-	"fyne.io/fyne/v2/canvas"
-	"fyne.io/fyne/v2/container"
-	"fyne.io/fyne/v2/layout"
-	"fyne.io/fyne/v2/theme"
-	"fyne.io/fyne/v2/widget"
-)
-
-var (
-	DEFAULT_TIMER_NAME    = "Current interval name"
-	DEFAULT_TIMER_DISPLAY = "00:00"
-)
-
-type gui struct {
-	application         *application
-	intervals           *widget.Select
-	sounds              *widget.Select
-	intervalDurationMin *widget.Select
-	intervalDurationSec *widget.Select
-	restDurationMin     *widget.Select
-	restDurationSec     *widget.Select
-	restBeforeStart     *widget.Check
-	timerName           *canvas.Text
-	timeRemaining       *canvas.Text
-	stopButton          *widget.Button
-	pauseButton         *widget.Button
-	startResumeButton   *widget.Button
-	skipButton          *widget.Button
-}
-
-func NewGui(app *application) *gui {
-	newGui := &gui{
-		application: app,
-	}
-
-	newGui.timerName = canvas.NewText(DEFAULT_TIMER_NAME, color.Black)
-	newGui.timerName.Alignment = fyne.TextAlignCenter
-	newGui.timeRemaining = canvas.NewText(DEFAULT_TIMER_DISPLAY, color.Gray16{3})
-	newGui.timeRemaining.TextSize = 50
-	newGui.timeRemaining.Alignment = fyne.TextAlignCenter
-
-	return newGui
-}
-
-func (g *gui) simpleViewWindow() fyne.Window {
-	w := g.application.guiDriver.NewWindow("simple view")
-
-	displayVBox := container.New(layout.NewVBoxLayout(), g.timerName, g.timeRemaining)
-
-	intervalsLabel := g.newCenteredText("# of Intervals", color.Black)
-	soundsLabel := g.newCenteredText("Sound", color.Black)
-	intervalLabel := g.newCenteredText("Interval", color.Black)
-	restLabel := g.newCenteredText("Rest", color.Black)
-	restBeforeStartLabel := g.newCenteredText("Rest before start", color.Black)
-	g.intervals = widget.NewSelect(genIncrementingDigitStringSlice(1, g.application.cnf.MaxIntervals), g.handleIntervalsSelect)
-	g.sounds = widget.NewSelect(g.application.soundOptions(), g.handleSoundSelect)
-	g.sounds.SetSelected(g.application.cnf.InitialIntervalEndSoundName)
-	g.intervalDurationMin = &widget.Select{
-		Options:     genIncrementingDigitStringSlice(0, g.application.cnf.MaxTimerMins),
-		PlaceHolder: "MM",
-		OnChanged:   g.handleIntervalMinuteSelect,
-	}
-	g.intervalDurationSec = &widget.Select{
-		Options:     genIncrementingDigitStringSlice(0, g.application.cnf.MaxTimerSecs),
-		PlaceHolder: "SS",
-		OnChanged:   g.handleIntervalSecondSelect,
-	}
-	g.restDurationMin = &widget.Select{
-		Options:     genIncrementingDigitStringSlice(0, g.application.cnf.MaxTimerMins),
-		PlaceHolder: "MM",
-		OnChanged:   g.handleRestMinuteSelect,
-	}
-	g.restDurationSec = &widget.Select{
-		Options:     genIncrementingDigitStringSlice(0, g.application.cnf.MaxTimerSecs),
-		PlaceHolder: "SS",
-		OnChanged:   g.handleRestSecondSelect,
-	}
-	interval := container.New(layout.NewHBoxLayout(), g.intervalDurationMin, widget.NewLabel(":"), g.intervalDurationSec)
-	rest := container.New(layout.NewHBoxLayout(), g.restDurationMin, widget.NewLabel(":"), g.restDurationSec)
-	g.restBeforeStart = widget.NewCheck("", g.handleRestBeforeStartChecked)
-
-	settings := container.New(layout.NewGridLayout(2),
-		intervalsLabel, g.intervals,
-		intervalLabel, interval,
-		restLabel, rest,
-		restBeforeStartLabel, g.restBeforeStart,
-		soundsLabel, g.sounds)
-
-	g.stopButton = widget.NewButtonWithIcon("", theme.MediaStopIcon(), g.handleStopButtonTap)
-	g.pauseButton = widget.NewButtonWithIcon("", theme.MediaPauseIcon(), g.handlePauseButtonTap)
-	g.startResumeButton = widget.NewButtonWithIcon("", theme.MediaPlayIcon(), g.handleStartButtonTap)
-	g.skipButton = widget.NewButtonWithIcon("", theme.MediaFastForwardIcon(), g.handleSkipButtonTap)
-	g.startResumeButton.Enable()
-	g.pauseButton.Disable()
-	g.stopButton.Disable()
-	g.skipButton.Disable()
-	buttonGrid := container.New(layout.NewGridLayout(2), g.skipButton, g.pauseButton, g.stopButton, g.startResumeButton)
-
-	windowVBox := container.New(layout.NewVBoxLayout(), displayVBox, layout.NewSpacer(), settings, layout.NewSpacer(), buttonGrid)
-	w.SetContent(windowVBox)
-
-	return w
-}
-
-func (g *gui) reset() {
-
-	g.updateTimerName(DEFAULT_TIMER_NAME)
-	g.updateTimerDisplay(DEFAULT_TIMER_DISPLAY)
-	g.intervals.Enable()
-	g.intervalDurationMin.Enable()
-	g.intervalDurationSec.Enable()
-	g.restDurationMin.Enable()
-	g.restDurationSec.Enable()
-	g.restBeforeStart.Enable()
-	g.startResumeButton.Enable()
-	g.pauseButton.Disable()
-	g.stopButton.Disable()
-	g.skipButton.Disable()
-	g.startResumeButton.OnTapped = g.handleStartButtonTap
-}
-
-func (g *gui) updateTimerName(text string) {
-	g.timerName.Text = text
-	g.timerName.Refresh()
-}
-
-func (g *gui) updateTimerDisplay(text string) {
-	g.timeRemaining.Text = text
-	g.timeRemaining.Refresh()
-}
-
-func (g *gui) handleIntervalsSelect(s string) {
-	g.application.timerConfig.Intervals = DIGIT_MAP[s]
-}
-
-func (g *gui) handleSoundSelect(s string) {
-	g.application.intervalFinishSound = g.application.sounds[s]
-	g.application.timerFinishSound = g.application.sounds[s]
-}
-
-func (g *gui) handleIntervalMinuteSelect(s string) {
-	g.application.timerConfig.IntervalMinutes = int64(DIGIT_MAP[s])
-}
-
-func (g *gui) handleIntervalSecondSelect(s string) {
-	g.application.timerConfig.IntervalSeconds = int64(DIGIT_MAP[s])
-}
-
-func (g *gui) handleRestMinuteSelect(s string) {
-	g.application.timerConfig.RestMinutes = int64(DIGIT_MAP[s])
-}
-
-func (g *gui) handleRestSecondSelect(s string) {
-	g.application.timerConfig.RestSeconds = int64(DIGIT_MAP[s])
-}
-
-func (g *gui) handleRestBeforeStartChecked(checked bool) {
-	g.application.timerConfig.RestBeforeStart = checked
-}
-
-func (g *gui) handleStartButtonTap() {
-	g.intervals.Disable()
-	g.intervalDurationMin.Disable()
-	g.intervalDurationSec.Disable()
-	g.restDurationMin.Disable()
-	g.restDurationSec.Disable()
-	g.restBeforeStart.Disable()
-
-	g.pauseButton.Enable()
-	g.stopButton.Enable()
-	g.startResumeButton.Disable()
-	g.skipButton.Enable()
-	g.startResumeButton.OnTapped = g.handleResumeButtonTap
-	g.application.runTimer()
-}
-
-func (g *gui) handlePauseButtonTap() {
-	g.pauseButton.Disable()
-	g.startResumeButton.Enable()
-	g.application.handleTimerPause()
-}
-
-func (g *gui) handleResumeButtonTap() {
-	g.pauseButton.Enable()
-	g.application.handleTimerResume()
-}
-
-func (g *gui) handleSkipButtonTap() {
-	g.application.handleTimerSkip()
-}
-
-func (g *gui) handleStopButtonTap() {
-	g.application.handleTimerCancel()
-	g.reset()
-}
-
-func (g gui) newCenteredText(text string, color color.Color) *canvas.Text {
-	newText := canvas.NewText(text, color)
-	newText.Alignment = fyne.TextAlignCenter
-	return newText
-}
+package timer
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"log"
+	"math"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/gabriel-ross/timer-go/internal"
+	"github.com/gabriel-ross/timer-go/preset"
+	"github.com/skip2/go-qrcode"
+)
+
+var (
+	DEFAULT_TIMER_NAME      = "Current interval name"
+	DEFAULT_TIMER_DISPLAY   = "00:00"
+	ANNOUNCE_COUNTDOWN_FROM = 3
+	WAVEFORM_BUCKETS        = 400 // Number of peaks drawn in the sound editor's waveform
+	AUDIO_FILE_EXTENSIONS   = []string{".mp3", ".wav", ".wave", ".flac", ".ogg", ".oga"}
+	PRESET_FILE_EXTENSIONS  = []string{".json", ".yaml", ".yml"}
+	TIMELINE_WIDTH          = 400 // Width of program timeline previews
+	QR_CODE_SIZE            = 256 // Width and height of shared preset QR codes in pixels
+	REPEAT_ONCE             = "Once"
+	REPEAT_FOREVER          = "Forever"
+	REPEAT_UNTIL            = "Until"
+	SCHEDULE_DAYS           = []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday, time.Sunday}
+	SCHEDULE_CURRENT        = "Current settings" // Option for a scheduled start that doesn't load a preset
+)
+
+type gui struct {
+	application         *application
+	window              fyne.Window
+	intervals           *widget.Select
+	program             *widget.Label
+	editProgram         *widget.Button
+	ladderProgram       *widget.Button
+	planProgram         *widget.Button
+	clearProgram        *widget.Button
+	seed                *widget.Entry
+	timeCap             *widget.Entry
+	repeat              *widget.Select
+	repeatUntil         *widget.Entry
+	session             *widget.Label
+	editSession         *widget.Button
+	clearSession        *widget.Button
+	schedule            *widget.Label
+	editSchedule        *widget.Button
+	cancelSchedule      *widget.Button
+	presets             *widget.Select
+	savePresetAs        *widget.Button
+	managePresets       *widget.Button
+	openPreset          *widget.Button
+	savePreset          *widget.Button
+	sounds              *widget.Select
+	previewSound        *widget.Button
+	editSound           *widget.Button
+	manageSounds        *widget.Button
+	soundPack           *widget.Select
+	intervalDurationMin *widget.Select
+	intervalDurationSec *widget.Select
+	restDurationMin     *widget.Select
+	restDurationSec     *widget.Select
+	restBeforeStart     *widget.Check
+	workStartBPM        *widget.Entry
+	workEndBPM          *widget.Entry
+	restStartBPM        *widget.Entry
+	restEndBPM          *widget.Entry
+	announcements       *widget.Check
+	masterVolume        *widget.Slider
+	soundVolume         *widget.Slider
+	workMusic           *widget.Button
+	restMusic           *widget.Button
+	clearWorkMusic      *widget.Button
+	clearRestMusic      *widget.Button
+	shuffleMusic        *widget.Check
+	alarm               *widget.Check
+	alarmDialog         dialog.Dialog
+	timerName           *canvas.Text
+	timeRemaining       *canvas.Text
+	trackStatus         *canvas.Text
+	progress            *widget.ProgressBar
+	shownProgress       internal.Progress
+	stopButton          *widget.Button
+	pauseButton         *widget.Button
+	startResumeButton   *widget.Button
+	skipButton          *widget.Button
+}
+
+func NewGui(app *application) *gui {
+	newGui := &gui{
+		application: app,
+	}
+
+	newGui.timerName = canvas.NewText(DEFAULT_TIMER_NAME, color.Black)
+	newGui.timerName.Alignment = fyne.TextAlignCenter
+	newGui.timeRemaining = canvas.NewText(DEFAULT_TIMER_DISPLAY, color.Gray16{3})
+	newGui.timeRemaining.TextSize = 50
+	newGui.timeRemaining.Alignment = fyne.TextAlignCenter
+	newGui.trackStatus = canvas.NewText("", color.Black)
+	newGui.trackStatus.Alignment = fyne.TextAlignCenter
+	newGui.progress = widget.NewProgressBar()
+	newGui.progress.TextFormatter = func() string {
+		percent := 0.0
+		if newGui.progress.Max > 0 {
+			percent = newGui.progress.Value / newGui.progress.Max * 100
+		}
+		text := fmt.Sprintf("%.0f%%", percent)
+		if part := newGui.shownProgress.Part; part != "" {
+			text = part + ", " + text
+		}
+		if newGui.application.timerConfig.Repeat {
+			text = fmt.Sprintf("Round %d, %s", newGui.shownProgress.Round, text)
+		}
+		return text
+	}
+
+	return newGui
+}
+
+func (g *gui) simpleViewWindow() fyne.Window {
+	w := g.application.guiDriver.NewWindow("simple view")
+	g.window = w
+
+	displayVBox := container.New(layout.NewVBoxLayout(), g.timerName, g.timeRemaining, g.trackStatus, g.progress)
+
+	presetLabel := g.newCenteredText("Preset", color.Black)
+	programLabel := g.newCenteredText("Program", color.Black)
+	seedLabel := g.newCenteredText("Random seed", color.Black)
+	timeCapLabel := g.newCenteredText("Time cap", color.Black)
+	sessionLabel := g.newCenteredText("Session", color.Black)
+	repeatLabel := g.newCenteredText("Repeat", color.Black)
+	scheduleLabel := g.newCenteredText("Scheduled start", color.Black)
+	intervalsLabel := g.newCenteredText("# of Intervals", color.Black)
+	soundsLabel := g.newCenteredText("Sound", color.Black)
+	intervalLabel := g.newCenteredText("Interval", color.Black)
+	restLabel := g.newCenteredText("Rest", color.Black)
+	restBeforeStartLabel := g.newCenteredText("Rest before start", color.Black)
+	workBPMLabel := g.newCenteredText("Work metronome", color.Black)
+	restBPMLabel := g.newCenteredText("Rest metronome", color.Black)
+	announcementsLabel := g.newCenteredText("Announcements", color.Black)
+	masterVolumeLabel := g.newCenteredText("Volume", color.Black)
+	soundVolumeLabel := g.newCenteredText("Sound volume", color.Black)
+	workMusicLabel := g.newCenteredText("Work music", color.Black)
+	restMusicLabel := g.newCenteredText("Rest music", color.Black)
+	shuffleMusicLabel := g.newCenteredText("Shuffle music", color.Black)
+	alarmLabel := g.newCenteredText("Alarm until dismissed", color.Black)
+	soundLibraryLabel := g.newCenteredText("Sound library", color.Black)
+	soundPackLabel := g.newCenteredText("Sound pack", color.Black)
+	g.intervals = widget.NewSelect(genIncrementingDigitStringSlice(1, g.application.cnf.MaxIntervals), g.handleIntervalsSelect)
+	g.masterVolume = widget.NewSlider(0, 100)
+	g.masterVolume.SetValue(g.application.MasterVolume() * 100)
+	g.masterVolume.OnChanged = g.handleMasterVolumeChanged
+	g.soundVolume = widget.NewSlider(0, MAX_SOUND_VOLUME*100)
+	g.soundVolume.OnChanged = g.handleSoundVolumeChanged
+	g.sounds = widget.NewSelect(g.application.soundOptions(), g.handleSoundSelect)
+	g.previewSound = widget.NewButtonWithIcon("", theme.MediaPlayIcon(), g.handlePreviewSoundButtonTap)
+	g.editSound = widget.NewButtonWithIcon("", theme.DocumentCreateIcon(), g.handleEditSoundButtonTap)
+	g.manageSounds = widget.NewButton("Manage", g.handleManageSoundsButtonTap)
+	g.soundPack = widget.NewSelect(g.application.SoundPacks(), nil)
+	g.soundPack.PlaceHolder = "None"
+	g.soundPack.SetSelected(g.application.SoundPack())
+	g.soundPack.OnChanged = g.handleSoundPackSelect
+	if len(g.soundPack.Options) == 0 {
+		g.soundPack.Disable()
+	}
+	g.sounds.SetSelected(g.application.cnf.InitialIntervalEndSoundName)
+	sounds := container.NewBorder(nil, nil, nil, container.NewHBox(g.previewSound, g.editSound), g.sounds)
+	g.intervalDurationMin = &widget.Select{
+		Options:     genIncrementingDigitStringSlice(0, g.application.cnf.MaxTimerMins),
+		PlaceHolder: "MM",
+		OnChanged:   g.handleIntervalMinuteSelect,
+	}
+	g.intervalDurationSec = &widget.Select{
+		Options:     genIncrementingDigitStringSlice(0, g.application.cnf.MaxTimerSecs),
+		PlaceHolder: "SS",
+		OnChanged:   g.handleIntervalSecondSelect,
+	}
+	g.restDurationMin = &widget.Select{
+		Options:     genIncrementingDigitStringSlice(0, g.application.cnf.MaxTimerMins),
+		PlaceHolder: "MM",
+		OnChanged:   g.handleRestMinuteSelect,
+	}
+	g.restDurationSec = &widget.Select{
+		Options:     genIncrementingDigitStringSlice(0, g.application.cnf.MaxTimerSecs),
+		PlaceHolder: "SS",
+		OnChanged:   g.handleRestSecondSelect,
+	}
+	interval := container.New(layout.NewHBoxLayout(), g.intervalDurationMin, widget.NewLabel(":"), g.intervalDurationSec)
+	rest := container.New(layout.NewHBoxLayout(), g.restDurationMin, widget.NewLabel(":"), g.restDurationSec)
+	g.restBeforeStart = widget.NewCheck("", g.handleRestBeforeStartChecked)
+	g.workStartBPM = g.newBPMEntry("BPM", g.handleWorkStartBPMChanged)
+	g.workEndBPM = g.newBPMEntry("to BPM", g.handleWorkEndBPMChanged)
+	g.restStartBPM = g.newBPMEntry("BPM", g.handleRestStartBPMChanged)
+	g.restEndBPM = g.newBPMEntry("to BPM", g.handleRestEndBPMChanged)
+	workBPM := container.NewGridWithColumns(2, g.workStartBPM, g.workEndBPM)
+	restBPM := container.NewGridWithColumns(2, g.restStartBPM, g.restEndBPM)
+	g.program = widget.NewLabel("")
+	g.clearProgram = widget.NewButtonWithIcon("", theme.ContentClearIcon(), g.handleClearProgramButtonTap)
+	g.editProgram = widget.NewButtonWithIcon("", theme.DocumentCreateIcon(), g.handleEditProgramButtonTap)
+	g.ladderProgram = widget.NewButton("Ladder", g.handleLadderProgramButtonTap)
+	g.planProgram = widget.NewButton("Plan", g.handlePlanProgramButtonTap)
+	program := container.NewBorder(nil, nil, nil, container.NewHBox(g.editProgram, g.ladderProgram, g.planProgram, g.clearProgram), g.program)
+	g.seed = widget.NewEntry()
+	g.seed.SetPlaceHolder("Random")
+	g.seed.Validator = validateSeed
+	g.timeCap = widget.NewEntry()
+	g.timeCap.SetPlaceHolder("None")
+	g.timeCap.Validator = validateWholeSeconds
+	g.timeCap.OnChanged = g.handleTimeCapChanged
+	g.repeatUntil = widget.NewEntry()
+	g.repeatUntil.SetPlaceHolder("18:00")
+	g.repeatUntil.Validator = validateTimeOfDay
+	g.repeatUntil.Disable()
+	g.repeat = widget.NewSelect([]string{REPEAT_ONCE, REPEAT_FOREVER, REPEAT_UNTIL}, g.handleRepeatSelect)
+	g.repeat.Selected = REPEAT_ONCE
+	repeat := container.NewGridWithColumns(2, g.repeat, g.repeatUntil)
+	g.session = widget.NewLabel("")
+	g.editSession = widget.NewButtonWithIcon("", theme.ListIcon(), g.showSessionEditor)
+	g.clearSession = widget.NewButtonWithIcon("", theme.ContentClearIcon(), g.handleClearSessionButtonTap)
+	session := container.NewBorder(nil, nil, nil, container.NewHBox(g.editSession, g.clearSession), g.session)
+	g.schedule = widget.NewLabel("")
+	g.editSchedule = widget.NewButtonWithIcon("", theme.HistoryIcon(), g.showScheduleEditor)
+	g.cancelSchedule = widget.NewButtonWithIcon("", theme.ContentClearIcon(), g.handleCancelScheduleButtonTap)
+	schedule := container.NewBorder(nil, nil, nil, container.NewHBox(g.editSchedule, g.cancelSchedule), g.schedule)
+	g.presets = widget.NewSelect(g.application.SavedPresets(), nil)
+	g.presets.PlaceHolder = "None"
+	g.presets.Selected = g.application.LastPreset()
+	g.presets.OnChanged = g.handlePresetSelect
+	g.savePresetAs = widget.NewButtonWithIcon("", theme.ContentAddIcon(), g.handleSavePresetAsButtonTap)
+	g.managePresets = widget.NewButton("Manage", g.handleManagePresetsButtonTap)
+	presets := container.NewBorder(nil, nil, nil, container.NewHBox(g.savePresetAs, g.managePresets), g.presets)
+	g.openPreset = widget.NewButtonWithIcon("Open file", theme.FolderOpenIcon(), g.handleOpenPresetButtonTap)
+	g.savePreset = widget.NewButtonWithIcon("Save file", theme.DocumentSaveIcon(), g.handleSavePresetButtonTap)
+	presetButtons := container.NewGridWithColumns(2, g.openPreset, g.savePreset)
+	g.announcements = widget.NewCheck("", g.handleAnnouncementsChecked)
+	if g.application.voice == nil {
+		g.announcements.Disable()
+	}
+	music := g.application.MusicConfig()
+	g.workMusic = widget.NewButton(musicButtonText(music.WorkDir), g.handleWorkMusicButtonTap)
+	g.restMusic = widget.NewButton(musicButtonText(music.RestDir), g.handleRestMusicButtonTap)
+	g.clearWorkMusic = widget.NewButtonWithIcon("", theme.ContentClearIcon(), g.handleClearWorkMusicButtonTap)
+	g.clearRestMusic = widget.NewButtonWithIcon("", theme.ContentClearIcon(), g.handleClearRestMusicButtonTap)
+	g.shuffleMusic = widget.NewCheck("", g.handleShuffleMusicChecked)
+	g.shuffleMusic.SetChecked(music.Shuffle)
+	g.alarm = widget.NewCheck("", g.handleAlarmChecked)
+	g.alarm.SetChecked(g.application.AlarmConfig().Enabled)
+	workMusic := container.NewBorder(nil, nil, nil, g.clearWorkMusic, g.workMusic)
+	restMusic := container.NewBorder(nil, nil, nil, g.clearRestMusic, g.restMusic)
+
+	settings := container.New(layout.NewGridLayout(2),
+		presetLabel, presets,
+		programLabel, program,
+		seedLabel, g.seed,
+		timeCapLabel, g.timeCap,
+		sessionLabel, session,
+		repeatLabel, repeat,
+		scheduleLabel, schedule,
+		intervalsLabel, g.intervals,
+		intervalLabel, interval,
+		restLabel, rest,
+		restBeforeStartLabel, g.restBeforeStart,
+		workBPMLabel, workBPM,
+		restBPMLabel, restBPM,
+		announcementsLabel, g.announcements,
+		soundsLabel, sounds,
+		alarmLabel, g.alarm,
+		soundLibraryLabel, g.manageSounds,
+		soundPackLabel, g.soundPack,
+		soundVolumeLabel, g.soundVolume,
+		masterVolumeLabel, g.masterVolume,
+		workMusicLabel, workMusic,
+		restMusicLabel, restMusic,
+		shuffleMusicLabel, g.shuffleMusic)
+
+	g.stopButton = widget.NewButtonWithIcon("", theme.MediaStopIcon(), g.handleStopButtonTap)
+	g.pauseButton = widget.NewButtonWithIcon("", theme.MediaPauseIcon(), g.handlePauseButtonTap)
+	g.startResumeButton = widget.NewButtonWithIcon("", theme.MediaPlayIcon(), g.handleStartButtonTap)
+	g.skipButton = widget.NewButtonWithIcon("", theme.MediaFastForwardIcon(), g.handleSkipButtonTap)
+	g.startResumeButton.Enable()
+	g.pauseButton.Disable()
+	g.stopButton.Disable()
+	g.skipButton.Disable()
+	buttonGrid := container.New(layout.NewGridLayout(2), g.skipButton, g.pauseButton, g.stopButton, g.startResumeButton)
+
+	windowVBox := container.New(layout.NewVBoxLayout(), displayVBox, layout.NewSpacer(), presetButtons, settings, layout.NewSpacer(), buttonGrid)
+	w.SetContent(windowVBox)
+	g.updateProgram()
+	g.updateSession()
+	g.updateSchedule()
+	if g.application.LastPreset() != "" {
+		g.loadSettings()
+	}
+
+	return w
+}
+
+func (g *gui) reset() {
+
+	g.updateTimerName(DEFAULT_TIMER_NAME)
+	g.updateTimerDisplay(DEFAULT_TIMER_DISPLAY)
+	g.trackStatus.Text = ""
+	g.trackStatus.Refresh()
+	g.timeCap.Enable()
+	g.shownProgress = internal.Progress{}
+	g.progress.SetValue(0)
+	g.repeat.Enable()
+	if g.repeat.Selected == REPEAT_UNTIL {
+		g.repeatUntil.Enable()
+	}
+	g.editSession.Enable()
+	g.updateSession()
+	g.updateProgram()
+	for _, w := range []fyne.Disableable{g.presets, g.savePresetAs, g.managePresets, g.openPreset, g.savePreset} {
+		w.Enable()
+	}
+	g.workStartBPM.Enable()
+	g.workEndBPM.Enable()
+	g.restStartBPM.Enable()
+	g.restEndBPM.Enable()
+	if g.application.voice != nil {
+		g.announcements.Enable()
+	}
+	g.setMusicEnabled(true)
+	g.startResumeButton.Enable()
+	g.pauseButton.Disable()
+	g.stopButton.Disable()
+	g.skipButton.Disable()
+	g.startResumeButton.OnTapped = g.handleStartButtonTap
+}
+
+func (g *gui) updateTimerName(text string) {
+	g.timerName.Text = text
+	g.timerName.Refresh()
+}
+
+func (g *gui) updateTimerDisplay(text string) {
+	g.timeRemaining.Text = text
+	g.timeRemaining.Refresh()
+}
+
+// updateProgress shows how far the timer has got through its program or
+// session.
+func (g *gui) updateProgress(progress internal.Progress) {
+	g.shownProgress = progress
+	g.progress.Max = progress.Total.Seconds()
+	g.progress.SetValue(progress.Done.Seconds())
+}
+
+// updateTrack shows the time remaining in a track running alongside the
+// program, such as the time cap.
+func (g *gui) updateTrack(update internal.TrackUpdate) {
+	g.trackStatus.Text = fmt.Sprintf("%s %s", update.Name, update.TimeRemaining)
+	if update.Finished {
+		g.trackStatus.Text = update.Name + " reached"
+	}
+	g.trackStatus.Refresh()
+}
+
+// updateProgram shows whether the timer runs a program of segments from a
+// preset or the interval notation or the interval settings, enabling the
+// interval settings only in the latter case.
+func (g *gui) updateProgram() {
+	g.editProgram.Enable()
+	g.ladderProgram.Enable()
+	g.planProgram.Enable()
+	program := g.application.program
+	if program == nil || !program.Random() {
+		g.seed.SetText("")
+		g.seed.Disable()
+	} else {
+		g.seed.Enable()
+	}
+	if program == nil {
+		g.program.SetText("Intervals")
+		g.clearProgram.Disable()
+		g.setIntervalSettingsEnabled(true)
+		if g.application.timerConfig.Repeat {
+			// each round of a repeating timer is a single interval
+			g.intervals.Disable()
+		}
+		return
+	}
+	g.program.SetText(describeSteps(program.Flatten()))
+	g.clearProgram.Enable()
+	g.setIntervalSettingsEnabled(false)
+}
+
+// describeSteps returns the number of steps and their total length.
+func describeSteps(steps []preset.Segment) string {
+	var shortest, longest time.Duration
+	for _, step := range steps {
+		shortest += time.Duration(step.Duration)
+		if step.MaxDuration != 0 {
+			longest += time.Duration(step.MaxDuration)
+		} else {
+			longest += time.Duration(step.Duration)
+		}
+	}
+	if longest != shortest {
+		return fmt.Sprintf("%d segments, %s to %s", len(steps), shortest, longest)
+	}
+	return fmt.Sprintf("%d segments, %s", len(steps), shortest)
+}
+
+// timeline draws the steps of a program as a bar, with each step's width
+// proportional to its length. Randomized steps are drawn at their shortest.
+type timeline struct {
+	ends   []time.Duration // End of each step from the start of the program
+	phases []preset.Phase
+	raster *canvas.Raster
+}
+
+func newTimeline() *timeline {
+	t := &timeline{}
+	t.raster = canvas.NewRasterWithPixels(func(x, y, w, h int) color.Color {
+		if len(t.ends) == 0 {
+			return color.Transparent
+		}
+		total := t.ends[len(t.ends)-1]
+		at := time.Duration(int64(total) * int64(x) / int64(w))
+		i := sort.Search(len(t.ends), func(i int) bool { return t.ends[i] > at })
+		if i == len(t.ends) {
+			i--
+		}
+		// a gap at the start of each step separates steps of the same phase
+		if i > 0 && time.Duration(int64(total)*int64(x-1)/int64(w)) < t.ends[i-1] {
+			return color.White
+		}
+		if t.phases[i] == preset.Rest {
+			return color.Gray16{0xaaaa}
+		}
+		return theme.PrimaryColor()
+	})
+	t.raster.SetMinSize(fyne.NewSize(float32(TIMELINE_WIDTH), 24))
+	return t
+}
+
+func (t *timeline) set(steps []preset.Segment) {
+	t.ends, t.phases = make([]time.Duration, len(steps)), make([]preset.Phase, len(steps))
+	var end time.Duration
+	for i, step := range steps {
+		end += time.Duration(step.Duration)
+		t.ends[i], t.phases[i] = end, step.Phase
+	}
+	t.raster.Refresh()
+}
+
+func (g *gui) setIntervalSettingsEnabled(enabled bool) {
+	for _, w := range []fyne.Disableable{g.intervals, g.intervalDurationMin, g.intervalDurationSec, g.restDurationMin, g.restDurationSec, g.restBeforeStart} {
+		if enabled {
+			w.Enable()
+		} else {
+			w.Disable()
+		}
+	}
+}
+
+// loadSettings updates every setting to show the application's current
+// configuration. Widgets are updated directly so that their change handlers
+// don't modify the configuration.
+func (g *gui) loadSettings() {
+	cnf := g.application.timerConfig
+	setSelected := func(s *widget.Select, v int64) {
+		s.Selected = ""
+		if g.application.program == nil {
+			s.Selected = strconv.FormatInt(v, 10)
+		}
+		s.Refresh()
+	}
+	setSelected(g.intervals, int64(cnf.Intervals))
+	setSelected(g.intervalDurationMin, cnf.IntervalMinutes)
+	setSelected(g.intervalDurationSec, cnf.IntervalSeconds)
+	setSelected(g.restDurationMin, cnf.RestMinutes)
+	setSelected(g.restDurationSec, cnf.RestSeconds)
+	setChecked := func(c *widget.Check, checked bool) {
+		c.Checked = checked
+		c.Refresh()
+	}
+	setChecked(g.restBeforeStart, cnf.RestBeforeStart)
+	setChecked(g.announcements, cnf.CountdownFrom > 0 || cnf.AnnounceHalfway || cnf.AnnounceIntervals)
+	setChecked(g.alarm, g.application.AlarmConfig().Enabled)
+	setText := func(e *widget.Entry, bpm float64) {
+		e.Text = ""
+		if bpm > 0 {
+			e.Text = strconv.FormatFloat(bpm, 'f', -1, 64)
+		}
+		e.Refresh()
+	}
+	setText(g.workStartBPM, cnf.WorkMetronome.StartBPM)
+	setText(g.workEndBPM, cnf.WorkMetronome.EndBPM)
+	setText(g.restStartBPM, cnf.RestMetronome.StartBPM)
+	setText(g.restEndBPM, cnf.RestMetronome.EndBPM)
+	g.timeCap.Text = ""
+	if limit := g.application.TimeCap(); limit > 0 {
+		g.timeCap.Text = limit.String()
+	}
+	g.timeCap.Refresh()
+
+	g.sounds.Selected = g.application.CueSound(IntervalEndRole)
+	g.refreshSoundOptions()
+	g.soundPack.Selected = g.application.SoundPack()
+	g.soundPack.Refresh()
+	music := g.application.MusicConfig()
+	g.workMusic.SetText(musicButtonText(music.WorkDir))
+	g.restMusic.SetText(musicButtonText(music.RestDir))
+	setChecked(g.shuffleMusic, music.Shuffle)
+	g.updateProgram()
+}
+
+func (g *gui) handleClearProgramButtonTap() {
+	g.application.ClearProgram()
+	g.loadSettings()
+}
+
+// handleEditProgramButtonTap shows the program in the interval notation for
+// editing, checking it as it is typed.
+func (g *gui) handleEditProgramButtonTap() {
+	entry := widget.NewMultiLineEntry()
+	entry.Wrapping = fyne.TextWrapWord
+	entry.SetPlaceHolder(`prep 10s; 3x(8x(20s work "Sprint", 10s rest), 2m rest); cooldown 5m`)
+	status := widget.NewLabel("")
+	status.Wrapping = fyne.TextWrapWord
+	preview := newTimeline()
+	entry.OnChanged = func(s string) {
+		program, err := preset.ParseProgram(s)
+		if err != nil {
+			status.SetText(err.Error())
+			return
+		}
+		steps := program.Flatten()
+		status.SetText(describeSteps(steps))
+		preview.set(steps)
+	}
+	entry.SetText(g.application.Program().String())
+	content := container.NewBorder(nil, container.NewVBox(preview.raster, status), nil, nil, entry)
+	edit := dialog.NewCustomConfirm("Program", "Apply", "Cancel", content, func(apply bool) {
+		if !apply {
+			return
+		}
+		program, err := preset.ParseProgram(entry.Text)
+		if err == nil {
+			err = g.application.SetProgram(program)
+		}
+		if err != nil {
+			dialog.ShowError(err, g.window)
+			return
+		}
+		g.loadSettings()
+	}, g.window)
+	edit.Resize(fyne.NewSize(500, 250))
+	edit.Show()
+}
+
+// handleLadderProgramButtonTap shows a form for generating a ladder or
+// pyramid program, previewing its timeline as it is edited.
+func (g *gui) handleLadderProgramButtonTap() {
+	ladder := preset.Ladder{Rounds: 3, Work: preset.Duration(30 * time.Second), Step: preset.Duration(10 * time.Second), RestRatio: 0.5}
+	if current := g.application.Program().Ladder; current != nil {
+		ladder = *current
+	}
+	rounds := widget.NewEntry()
+	rounds.SetText(strconv.Itoa(ladder.Rounds))
+	work := widget.NewEntry()
+	work.SetText(ladder.Work.String())
+	step := widget.NewEntry()
+	step.SetText(ladder.Step.String())
+	pyramid := widget.NewCheck("", nil)
+	pyramid.SetChecked(ladder.Pyramid)
+	rest := widget.NewEntry()
+	rest.SetText(ladder.Rest.String())
+	restRatio := widget.NewEntry()
+	restRatio.SetPlaceHolder("Rest as a multiple of work, such as 0.5")
+	if ladder.RestRatio != 0 {
+		restRatio.SetText(strconv.FormatFloat(ladder.RestRatio, 'f', -1, 64))
+	}
+	status := widget.NewLabel("")
+	status.Wrapping = fyne.TextWrapWord
+	preview := newTimeline()
+
+	// read returns the ladder described by the form
+	read := func() (preset.Ladder, error) {
+		l := preset.Ladder{Pyramid: pyramid.Checked}
+		var err error
+		if l.Rounds, err = strconv.Atoi(rounds.Text); err != nil {
+			return l, errors.New("rounds must be a whole number")
+		}
+		for _, d := range []struct {
+			entry *widget.Entry
+			value *preset.Duration
+			name  string
+		}{{work, &l.Work, "work"}, {step, &l.Step, "step"}, {rest, &l.Rest, "rest"}} {
+			if d.entry.Text == "" {
+				continue
+			}
+			parsed, err := time.ParseDuration(d.entry.Text)
+			if err != nil {
+				return l, fmt.Errorf("%s must be a duration such as 30s or 1m30s", d.name)
+			}
+			*d.value = preset.Duration(parsed)
+		}
+		if restRatio.Text != "" {
+			if l.RestRatio, err = strconv.ParseFloat(restRatio.Text, 64); err != nil {
+				return l, errors.New("rest ratio must be a number")
+			}
+		}
+		p := preset.New("")
+		p.Program.Ladder = &l
+		return l, p.Validate()
+	}
+	update := func() {
+		l, err := read()
+		if err != nil {
+			status.SetText(err.Error())
+			preview.set(nil)
+			return
+		}
+		steps := preset.Program{Ladder: &l}.Flatten()
+		status.SetText(describeSteps(steps))
+		preview.set(steps)
+	}
+	for _, entry := range []*widget.Entry{rounds, work, step, rest, restRatio} {
+		entry.OnChanged = func(string) { update() }
+	}
+	pyramid.OnChanged = func(bool) { update() }
+	update()
+
+	form := widget.NewForm(
+		widget.NewFormItem("Rounds", rounds),
+		widget.NewFormItem("First work", work),
+		widget.NewFormItem("Step", step),
+		widget.NewFormItem("Pyramid", pyramid),
+		widget.NewFormItem("Rest", rest),
+		widget.NewFormItem("Rest ratio", restRatio),
+	)
+	content := container.NewVBox(form, preview.raster, status)
+	d := dialog.NewCustomConfirm("Ladder", "Apply", "Cancel", content, func(apply bool) {
+		if !apply {
+			return
+		}
+		l, err := read()
+		if err == nil {
+			err = g.application.SetProgram(preset.Program{Ladder: &l, Cap: preset.Duration(g.application.TimeCap())})
+		}
+		if err != nil {
+			dialog.ShowError(err, g.window)
+			return
+		}
+		g.loadSettings()
+	}, g.window)
+	d.Resize(fyne.NewSize(float32(TIMELINE_WIDTH)+50, 0))
+	d.Show()
+}
+
+// handlePlanProgramButtonTap shows a form for generating a program that fits
+// a time budget, previewing its timeline and the adjustments made to fit it
+// in whole seconds as it is edited.
+func (g *gui) handlePlanProgramButtonTap() {
+	budget := widget.NewEntry()
+	budget.SetText("20m")
+	ratio := widget.NewEntry()
+	ratio.SetText("3:1")
+	stations := widget.NewEntry()
+	stations.SetText("6")
+	rounds := widget.NewEntry()
+	rounds.SetText("1")
+	warmUp := widget.NewEntry()
+	warmUp.SetPlaceHolder("None")
+	coolDown := widget.NewEntry()
+	coolDown.SetPlaceHolder("None")
+	status := widget.NewLabel("")
+	status.Wrapping = fyne.TextWrapWord
+	preview := newTimeline()
+
+	// read returns the program planned by the form
+	read := func() (*preset.PlanResult, error) {
+		p := preset.Plan{}
+		var err error
+		if p.WorkRatio, p.RestRatio, err = preset.ParseRatio(ratio.Text); err != nil {
+			return nil, err
+		}
+		if p.Stations, err = strconv.Atoi(stations.Text); err != nil {
+			return nil, errors.New("stations must be a whole number")
+		}
+		if p.Rounds, err = strconv.Atoi(rounds.Text); err != nil {
+			return nil, errors.New("rounds must be a whole number")
+		}
+		for _, d := range []struct {
+			entry *widget.Entry
+			value *preset.Duration
+			name  string
+		}{{budget, &p.Budget, "budget"}, {warmUp, &p.WarmUp, "warm up"}, {coolDown, &p.CoolDown, "cool down"}} {
+			if d.entry.Text == "" {
+				continue
+			}
+			parsed, err := time.ParseDuration(d.entry.Text)
+			if err != nil {
+				return nil, fmt.Errorf("%s must be a duration such as 20m or 2m30s", d.name)
+			}
+			*d.value = preset.Duration(parsed)
+		}
+		return p.Generate()
+	}
+	update := func() {
+		result, err := read()
+		if err != nil {
+			status.SetText(err.Error())
+			preview.set(nil)
+			return
+		}
+		steps := result.Program.Flatten()
+		status.SetText(strings.Join(append([]string{describeSteps(steps)}, result.Adjustments...), "\n"))
+		preview.set(steps)
+	}
+	for _, entry := range []*widget.Entry{budget, ratio, stations, rounds, warmUp, coolDown} {
+		entry.OnChanged = func(string) { update() }
+	}
+	update()
+
+	form := widget.NewForm(
+		widget.NewFormItem("Budget", budget),
+		widget.NewFormItem("Work:rest", ratio),
+		widget.NewFormItem("Stations", stations),
+		widget.NewFormItem("Rounds", rounds),
+		widget.NewFormItem("Warm up", warmUp),
+		widget.NewFormItem("Cool down", coolDown),
+	)
+	content := container.NewVBox(form, preview.raster, status)
+	d := dialog.NewCustomConfirm("Plan", "Apply", "Cancel", content, func(apply bool) {
+		if !apply {
+			return
+		}
+		result, err := read()
+		if err == nil {
+			result.Program.Cap = preset.Duration(g.application.TimeCap())
+			err = g.application.SetProgram(result.Program)
+		}
+		if err != nil {
+			dialog.ShowError(err, g.window)
+			return
+		}
+		g.loadSettings()
+	}, g.window)
+	d.Resize(fyne.NewSize(float32(TIMELINE_WIDTH)+50, 0))
+	d.Show()
+}
+
+func (g *gui) handlePresetSelect(name string) {
+	err := g.application.LoadPreset(name)
+	g.loadSettings()
+	if err != nil {
+		dialog.ShowError(err, g.window)
+	}
+}
+
+// refreshPresetOptions updates the preset select after the preset library
+// has changed.
+func (g *gui) refreshPresetOptions() {
+	g.presets.Options = g.application.SavedPresets()
+	g.presets.Selected = g.application.LastPreset()
+	g.presets.Refresh()
+}
+
+func (g *gui) handleSavePresetAsButtonTap() {
+	entry := widget.NewEntry()
+	entry.SetText(g.application.LastPreset())
+	dialog.ShowForm("Save preset", "Save", "Cancel", []*widget.FormItem{widget.NewFormItem("Name", entry)}, func(save bool) {
+		if !save {
+			return
+		}
+		name := entry.Text
+		saveAs := func() {
+			if err := g.application.SavePreset(name); err != nil {
+				dialog.ShowError(err, g.window)
+			}
+			g.refreshPresetOptions()
+		}
+		for _, saved := range g.application.SavedPresets() {
+			if saved == name {
+				dialog.ShowConfirm("Replace "+name, fmt.Sprintf("Replace the saved preset %q?", name), func(replace bool) {
+					if replace {
+						saveAs()
+					}
+				}, g.window)
+				return
+			}
+		}
+		saveAs()
+	}, g.window)
+}
+
+func (g *gui) handleManagePresetsButtonTap() {
+	g.showPresetManager()
+}
+
+// showPresetManager shows a dialog listing the saved presets with buttons to
+// load, rename, duplicate and delete them.
+func (g *gui) showPresetManager() {
+	names := g.application.SavedPresets()
+	selected := ""
+	list := widget.NewList(
+		func() int { return len(names) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(i widget.ListItemID, o fyne.CanvasObject) { o.(*widget.Label).SetText(names[i]) },
+	)
+
+	loadButton := widget.NewButtonWithIcon("Load", theme.ConfirmIcon(), nil)
+	renameButton := widget.NewButton("Rename", nil)
+	duplicateButton := widget.NewButtonWithIcon("Duplicate", theme.ContentCopyIcon(), nil)
+	deleteButton := widget.NewButtonWithIcon("Delete", theme.DeleteIcon(), nil)
+	shareButton := widget.NewButtonWithIcon("Share", theme.MailSendIcon(), nil)
+	importButton := widget.NewButtonWithIcon("Import from code", theme.ContentPasteIcon(), nil)
+	selectionButtons := []*widget.Button{loadButton, renameButton, duplicateButton, deleteButton, shareButton}
+	setSelected := func(name string) {
+		selected = name
+		for _, b := range selectionButtons {
+			if name == "" {
+				b.Disable()
+			} else {
+				b.Enable()
+			}
+		}
+	}
+	setSelected("")
+	list.OnSelected = func(i widget.ListItemID) { setSelected(names[i]) }
+	list.OnUnselected = func(widget.ListItemID) { setSelected("") }
+	refresh := func() {
+		names = g.application.SavedPresets()
+		list.UnselectAll()
+		list.Refresh()
+		g.refreshPresetOptions()
+		g.updateSession()
+		g.updateSchedule()
+	}
+
+	loadButton.OnTapped = func() {
+		g.handlePresetSelect(selected)
+		g.refreshPresetOptions()
+	}
+	renameButton.OnTapped = func() {
+		from := selected
+		entry := widget.NewEntry()
+		entry.SetText(from)
+		dialog.ShowForm("Rename "+from, "Rename", "Cancel", []*widget.FormItem{widget.NewFormItem("Name", entry)}, func(rename bool) {
+			if !rename || entry.Text == from {
+				return
+			}
+			if err := g.application.RenamePreset(from, entry.Text); err != nil {
+				dialog.ShowError(err, g.window)
+				return
+			}
+			refresh()
+		}, g.window)
+	}
+	duplicateButton.OnTapped = func() {
+		if _, err := g.application.DuplicatePreset(selected); err != nil {
+			dialog.ShowError(err, g.window)
+		}
+		refresh()
+	}
+	deleteButton.OnTapped = func() {
+		name := selected
+		dialog.ShowConfirm("Delete "+name, fmt.Sprintf("Delete the saved preset %q?", name), func(remove bool) {
+			if !remove {
+				return
+			}
+			if err := g.application.DeletePreset(name); err != nil {
+				dialog.ShowError(err, g.window)
+			}
+			refresh()
+		}, g.window)
+	}
+
+	shareButton.OnTapped = func() {
+		code, err := g.application.PresetCode(selected)
+		if err != nil {
+			dialog.ShowError(err, g.window)
+			return
+		}
+		g.showPresetCode(selected, code)
+	}
+	importButton.OnTapped = func() {
+		entry := widget.NewMultiLineEntry()
+		entry.Wrapping = fyne.TextWrapBreak
+		entry.SetPlaceHolder(preset.CODE_PREFIX + "...")
+		entry.SetText(g.window.Clipboard().Content())
+		if !strings.HasPrefix(entry.Text, preset.CODE_PREFIX[:3]) {
+			entry.SetText("")
+		}
+		d := dialog.NewCustomConfirm("Import from code", "Import", "Cancel", entry, func(add bool) {
+			if !add {
+				return
+			}
+			name, err := g.application.ImportPresetCode(entry.Text)
+			if err != nil {
+				dialog.ShowError(err, g.window)
+				return
+			}
+			refresh()
+			dialog.ShowInformation("Imported", fmt.Sprintf("Saved as %q.", name), g.window)
+		}, g.window)
+		d.Resize(fyne.NewSize(400, 200))
+		d.Show()
+	}
+
+	buttons := container.NewVBox(
+		container.NewHBox(loadButton, renameButton, duplicateButton, deleteButton),
+		container.NewHBox(shareButton, importButton),
+	)
+	d := dialog.NewCustom("Presets", "Close", container.NewBorder(nil, buttons, nil, nil, list), g.window)
+	d.Resize(fyne.NewSize(400, 400))
+	d.Show()
+}
+
+// updateSession shows the presets queued in the session.
+func (g *gui) updateSession() {
+	items := g.application.Session()
+	if len(items) == 0 {
+		g.session.SetText("None")
+		g.clearSession.Disable()
+		return
+	}
+	g.clearSession.Enable()
+	steps, err := g.application.SessionSteps(items)
+	if err != nil {
+		g.session.SetText(err.Error())
+		return
+	}
+	g.session.SetText(fmt.Sprintf("%d presets in place of the program, %s", len(items), describeSteps(steps)))
+}
+
+func (g *gui) handleClearSessionButtonTap() {
+	if err := g.application.SetSession(nil); err != nil {
+		dialog.ShowError(err, g.window)
+	}
+	g.updateSession()
+}
+
+// updateSchedule shows the start the timer is scheduled for.
+func (g *gui) updateSchedule() {
+	start := g.application.ScheduledStart()
+	if start == nil {
+		g.schedule.SetText("None")
+		g.cancelSchedule.Disable()
+		return
+	}
+	g.schedule.SetText(start.String())
+	g.cancelSchedule.Enable()
+}
+
+// updateScheduleCountdown shows the time until the scheduled start, or that
+// it has started or was missed.
+func (g *gui) updateScheduleCountdown(start ScheduledStart, update internal.ScheduleUpdate) {
+	switch {
+	case update.Missed:
+		g.schedule.SetText(fmt.Sprintf("%s, missed %s", start, update.Start.Format("Mon 15:04:05")))
+	case update.Started:
+		g.schedule.SetText(fmt.Sprintf("%s, started %s", start, update.Start.Format("Mon 15:04:05")))
+	default:
+		g.schedule.SetText(fmt.Sprintf("%s, starts in %s", start, update.TimeRemaining))
+	}
+}
+
+// showMissedStart tells the user that the scheduled start at at was missed.
+func (g *gui) showMissedStart(at time.Time) {
+	dialog.ShowInformation("Scheduled start", fmt.Sprintf("The start at %s was missed, as the timer couldn't start on time.", at.Format("Mon 15:04:05")), g.window)
+}
+
+// startScheduled starts the timer for a scheduled start once its preset has
+// been loaded.
+func (g *gui) startScheduled() {
+	g.loadSettings()
+	g.refreshPresetOptions()
+	g.handleStartButtonTap()
+}
+
+func (g *gui) handleCancelScheduleButtonTap() {
+	g.application.CancelScheduledStart()
+	g.updateSchedule()
+}
+
+// showScheduleEditor shows a form for scheduling the timer to start at a
+// time of day, once or on the chosen days of the week.
+func (g *gui) showScheduleEditor() {
+	presets := widget.NewSelect(append([]string{SCHEDULE_CURRENT}, g.application.SavedPresets()...), nil)
+	presets.SetSelected(SCHEDULE_CURRENT)
+	at := widget.NewEntry()
+	at.SetPlaceHolder("18:00")
+	at.Validator = func(s string) error {
+		if s == "" {
+			return errors.New("enter a time such as 18:00")
+		}
+		return validateTimeOfDay(s)
+	}
+	dayNames := make([]string, len(SCHEDULE_DAYS))
+	for i, day := range SCHEDULE_DAYS {
+		dayNames[i] = day.String()[:3]
+	}
+	days := widget.NewCheckGroup(dayNames, nil)
+	days.Horizontal = true
+	if start := g.application.ScheduledStart(); start != nil {
+		if start.Preset != "" {
+			presets.SetSelected(start.Preset)
+		}
+		at.SetText(start.Schedule.At.String())
+		for _, day := range start.Schedule.Days {
+			days.Selected = append(days.Selected, day.String()[:3])
+		}
+	}
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("Preset", presets),
+		widget.NewFormItem("At", at),
+		{Text: "Every", Widget: days, HintText: "None to start once"},
+	}
+	dialog.ShowForm("Scheduled start", "Schedule", "Cancel", items, func(ok bool) {
+		if !ok {
+			return
+		}
+		tod, err := internal.ParseTimeOfDay(at.Text)
+		if err != nil {
+			dialog.ShowError(err, g.window)
+			return
+		}
+		start := ScheduledStart{Schedule: internal.Schedule{At: tod}}
+		if presets.Selected != SCHEDULE_CURRENT {
+			start.Preset = presets.Selected
+		}
+		for i, name := range dayNames {
+			for _, selected := range days.Selected {
+				if selected == name {
+					start.Schedule.Days = append(start.Schedule.Days, SCHEDULE_DAYS[i])
+				}
+			}
+		}
+		if err := g.application.ScheduleStart(start); err != nil {
+			dialog.ShowError(err, g.window)
+		}
+	}, g.window)
+}
+
+// showSessionEditor shows the queue of presets in the session for adding,
+// removing and reordering them and setting the transitions between them,
+// previewing the session's timeline as it is edited.
+func (g *gui) showSessionEditor() {
+	// edit a copy so that the queued session only changes on Apply
+	items := append([]SessionItem{}, g.application.Session()...)
+	selected := -1
+	list := widget.NewList(
+		func() int { return len(items) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(i widget.ListItemID, o fyne.CanvasObject) {
+			text := fmt.Sprintf("%d. %s", i+1, items[i].Preset)
+			if items[i].Transition > 0 && i < len(items)-1 {
+				text += fmt.Sprintf(", then %s rest", items[i].Transition)
+			}
+			o.(*widget.Label).SetText(text)
+		},
+	)
+	add := widget.NewSelect(g.application.SavedPresets(), nil)
+	add.PlaceHolder = "Add a preset"
+	transition := widget.NewEntry()
+	transition.SetPlaceHolder("Rest after, such as 30s")
+	transition.Validator = validateWholeSeconds
+	upButton := widget.NewButtonWithIcon("", theme.MoveUpIcon(), nil)
+	downButton := widget.NewButtonWithIcon("", theme.MoveDownIcon(), nil)
+	removeButton := widget.NewButtonWithIcon("", theme.DeleteIcon(), nil)
+	status := widget.NewLabel("")
+	status.Wrapping = fyne.TextWrapWord
+	preview := newTimeline()
+
+	update := func() {
+		list.Refresh()
+		for _, w := range []fyne.Disableable{upButton, downButton, removeButton, transition} {
+			if selected < 0 {
+				w.Disable()
+			} else {
+				w.Enable()
+			}
+		}
+		if selected == 0 {
+			upButton.Disable()
+		}
+		if selected == len(items)-1 {
+			downButton.Disable()
+		}
+		steps, err := g.application.SessionSteps(items)
+		switch {
+		case err != nil:
+			status.SetText(err.Error())
+			preview.set(nil)
+		case len(items) == 0:
+			status.SetText("Add presets to run them back to back")
+			preview.set(nil)
+		default:
+			status.SetText(describeSteps(steps))
+			preview.set(steps)
+		}
+	}
+	list.OnSelected = func(i widget.ListItemID) {
+		selected = i
+		transition.Text = ""
+		if items[i].Transition > 0 {
+			transition.Text = items[i].Transition.String()
+		}
+		transition.Refresh()
+		update()
+	}
+	list.OnUnselected = func(widget.ListItemID) {
+		selected = -1
+		update()
+	}
+	add.OnChanged = func(name string) {
+		if name == "" {
+			return
+		}
+		items = append(items, SessionItem{Preset: name})
+		add.ClearSelected()
+		list.UnselectAll()
+		update()
+	}
+	move := func(by int) {
+		items[selected], items[selected+by] = items[selected+by], items[selected]
+		list.Select(selected + by)
+	}
+	upButton.OnTapped = func() { move(-1) }
+	downButton.OnTapped = func() { move(1) }
+	removeButton.OnTapped = func() {
+		items = append(items[:selected], items[selected+1:]...)
+		list.UnselectAll()
+		update()
+	}
+	transition.OnChanged = func(s string) {
+		if selected < 0 || validateWholeSeconds(s) != nil {
+			return
+		}
+		items[selected].Transition, _ = time.ParseDuration(s)
+		update()
+	}
+	update()
+
+	controls := container.NewBorder(nil, nil, nil, container.NewHBox(upButton, downButton, removeButton), transition)
+	bottom := container.NewVBox(add, controls, preview.raster, status)
+	d := dialog.NewCustomConfirm("Session", "Apply", "Cancel", container.NewBorder(nil, bottom, nil, nil, list), func(apply bool) {
+		if !apply {
+			return
+		}
+		if err := g.application.SetSession(items); err != nil {
+			dialog.ShowError(err, g.window)
+		}
+		g.updateSession()
+	}, g.window)
+	d.Resize(fyne.NewSize(float32(TIMELINE_WIDTH)+50, 450))
+	d.Show()
+}
+
+// showPresetCode shows the code for sharing a preset as text that can be
+// copied and as a QR code.
+func (g *gui) showPresetCode(name, code string) {
+	text := widget.NewLabel(code)
+	text.Wrapping = fyne.TextWrapBreak
+	copyButton := widget.NewButtonWithIcon("Copy", theme.ContentCopyIcon(), func() {
+		g.window.Clipboard().SetContent(code)
+	})
+	content := container.NewVBox(text, copyButton)
+	qr, err := qrcode.New(code, qrcode.Medium)
+	if err != nil {
+		log.Printf("error creating QR code: %v\n", err)
+	} else {
+		image := canvas.NewImageFromImage(qr.Image(QR_CODE_SIZE))
+		image.FillMode = canvas.ImageFillContain
+		image.SetMinSize(fyne.NewSize(float32(QR_CODE_SIZE), float32(QR_CODE_SIZE)))
+		content.Add(image)
+	}
+	d := dialog.NewCustom("Share "+name, "Close", content, g.window)
+	d.Resize(fyne.NewSize(400, 0))
+	d.Show()
+}
+
+func (g *gui) handleOpenPresetButtonTap() {
+	open := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, g.window)
+			return
+		}
+		if reader == nil {
+			return
+		}
+		defer reader.Close()
+		format, err := preset.FormatOf(reader.URI().Name())
+		if err != nil {
+			dialog.ShowError(err, g.window)
+			return
+		}
+		p, err := preset.Load(reader, format)
+		if err != nil {
+			dialog.ShowError(err, g.window)
+			return
+		}
+		err = g.application.ApplyPreset(p)
+		g.loadSettings()
+		if err != nil {
+			dialog.ShowError(err, g.window)
+		}
+	}, g.window)
+	open.SetFilter(storage.NewExtensionFileFilter(PRESET_FILE_EXTENSIONS))
+	open.Show()
+}
+
+func (g *gui) handleSavePresetButtonTap() {
+	save := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, g.window)
+			return
+		}
+		if writer == nil {
+			return
+		}
+		defer writer.Close()
+		name := writer.URI().Name()
+		format, err := preset.FormatOf(name)
+		if err != nil {
+			dialog.ShowError(err, g.window)
+			return
+		}
+		p := g.application.Preset(strings.TrimSuffix(name, writer.URI().Extension()))
+		if err = p.Save(writer, format); err != nil {
+			dialog.ShowError(err, g.window)
+		}
+	}, g.window)
+	save.SetFilter(storage.NewExtensionFileFilter(PRESET_FILE_EXTENSIONS))
+	save.SetFileName("preset.json")
+	save.Show()
+}
+
+func (g *gui) handleIntervalsSelect(s string) {
+	g.application.timerConfig.Intervals = DIGIT_MAP[s]
+}
+
+func (g *gui) handleSoundSelect(s string) {
+	g.application.intervalFinishSound = g.application.sounds[s]
+	g.application.timerFinishSound = g.application.sounds[s]
+	if volume, err := g.application.SoundVolume(s); err == nil {
+		g.soundVolume.SetValue(volume * 100)
+	}
+}
+
+func (g *gui) handlePreviewSoundButtonTap() {
+	if g.sounds.Selected == "" {
+		return
+	}
+	g.application.PreviewSound(g.sounds.Selected)
+}
+
+func (g *gui) handleEditSoundButtonTap() {
+	if g.sounds.Selected == "" {
+		return
+	}
+	g.showSoundEditor(g.sounds.Selected)
+}
+
+func (g *gui) handleSoundPackSelect(s string) {
+	if err := g.application.UseSoundPack(s); err != nil {
+		dialog.ShowError(err, g.window)
+		return
+	}
+	// the sound select shows the interval sound without changing the other
+	// sounds from the pack
+	g.sounds.Selected = g.application.CueSound(IntervalEndRole)
+	g.refreshSoundOptions()
+}
+
+func (g *gui) handleManageSoundsButtonTap() {
+	g.showSoundManager()
+}
+
+func (g *gui) handleMasterVolumeChanged(v float64) {
+	g.application.SetMasterVolume(v / 100)
+}
+
+func (g *gui) handleSoundVolumeChanged(v float64) {
+	if g.sounds.Selected == "" {
+		return
+	}
+	g.application.SetSoundVolume(g.sounds.Selected, v/100)
+}
+
+func (g *gui) handleIntervalMinuteSelect(s string) {
+	g.application.timerConfig.IntervalMinutes = int64(DIGIT_MAP[s])
+}
+
+func (g *gui) handleIntervalSecondSelect(s string) {
+	g.application.timerConfig.IntervalSeconds = int64(DIGIT_MAP[s])
+}
+
+func (g *gui) handleRestMinuteSelect(s string) {
+	g.application.timerConfig.RestMinutes = int64(DIGIT_MAP[s])
+}
+
+func (g *gui) handleRestSecondSelect(s string) {
+	g.application.timerConfig.RestSeconds = int64(DIGIT_MAP[s])
+}
+
+func (g *gui) handleRestBeforeStartChecked(checked bool) {
+	g.application.timerConfig.RestBeforeStart = checked
+}
+
+func (g *gui) handleWorkStartBPMChanged(s string) {
+	g.application.timerConfig.WorkMetronome.StartBPM = parseBPM(s)
+}
+
+func (g *gui) handleWorkEndBPMChanged(s string) {
+	g.application.timerConfig.WorkMetronome.EndBPM = parseBPM(s)
+}
+
+func (g *gui) handleRestStartBPMChanged(s string) {
+	g.application.timerConfig.RestMetronome.StartBPM = parseBPM(s)
+}
+
+func (g *gui) handleRestEndBPMChanged(s string) {
+	g.application.timerConfig.RestMetronome.EndBPM = parseBPM(s)
+}
+
+func (g *gui) handleAnnouncementsChecked(checked bool) {
+	g.application.timerConfig.CountdownFrom = 0
+	if checked {
+		g.application.timerConfig.CountdownFrom = ANNOUNCE_COUNTDOWN_FROM
+	}
+	g.application.timerConfig.AnnounceHalfway = checked
+	g.application.timerConfig.AnnounceIntervals = checked
+}
+
+func (g *gui) handleAlarmChecked(checked bool) {
+	alarm := g.application.AlarmConfig()
+	alarm.Enabled = checked
+	g.application.SetAlarmConfig(alarm)
+}
+
+func (g *gui) handleStartButtonTap() {
+	if g.seed.Text != "" {
+		seed, err := strconv.ParseInt(g.seed.Text, 10, 64)
+		if err != nil {
+			dialog.ShowError(validateSeed(g.seed.Text), g.window)
+			return
+		}
+		g.application.ReplaySeed(seed)
+	}
+	if g.repeat.Selected == REPEAT_UNTIL {
+		until, err := internal.ParseTimeOfDay(g.repeatUntil.Text)
+		if err != nil {
+			dialog.ShowError(err, g.window)
+			return
+		}
+		g.application.SetRepeat(true, until.Next(time.Now()))
+	}
+	g.application.DismissAlarm()
+	g.repeat.Disable()
+	g.repeatUntil.Disable()
+	g.seed.Disable()
+	g.timeCap.Disable()
+	g.editSession.Disable()
+	g.clearSession.Disable()
+	g.setIntervalSettingsEnabled(false)
+	g.editProgram.Disable()
+	g.ladderProgram.Disable()
+	g.planProgram.Disable()
+	g.clearProgram.Disable()
+	for _, w := range []fyne.Disableable{g.presets, g.savePresetAs, g.managePresets, g.openPreset, g.savePreset} {
+		w.Disable()
+	}
+	g.workStartBPM.Disable()
+	g.workEndBPM.Disable()
+	g.restStartBPM.Disable()
+	g.restEndBPM.Disable()
+	g.announcements.Disable()
+	g.setMusicEnabled(false)
+
+	g.pauseButton.Enable()
+	g.stopButton.Enable()
+	g.startResumeButton.Disable()
+	g.skipButton.Enable()
+	g.startResumeButton.OnTapped = g.handleResumeButtonTap
+	if err := g.application.runTimer(); err != nil {
+		dialog.ShowError(err, g.window)
+		g.reset()
+		return
+	}
+	if program := g.application.program; program != nil && program.Random() {
+		g.seed.SetPlaceHolder(fmt.Sprintf("Random, last run was %d", g.application.Seed()))
+	}
+}
+
+func (g *gui) handleTimeCapChanged(s string) {
+	if validateWholeSeconds(s) != nil {
+		return
+	}
+	limit, _ := time.ParseDuration(s)
+	g.application.SetTimeCap(limit)
+}
+
+func (g *gui) handleRepeatSelect(option string) {
+	switch option {
+	case REPEAT_ONCE:
+		g.application.SetRepeat(false, time.Time{})
+		g.repeatUntil.Disable()
+	case REPEAT_FOREVER:
+		g.application.SetRepeat(true, time.Time{})
+		g.repeatUntil.Disable()
+	case REPEAT_UNTIL:
+		// the time is chosen when the timer starts
+		g.application.SetRepeat(true, time.Time{})
+		g.repeatUntil.Enable()
+	}
+	g.updateProgram()
+}
+
+// validateTimeOfDay returns an error if s isn't empty or a time of day.
+func validateTimeOfDay(s string) error {
+	if s == "" {
+		return nil
+	}
+	_, err := internal.ParseTimeOfDay(s)
+	return err
+}
+
+// showSummary shows what a repeating run did once it has ended.
+func (g *gui) showSummary(summary internal.Summary) {
+	dialog.ShowInformation("Summary", summary.String(), g.window)
+}
+
+// validateWholeSeconds returns an error if s isn't empty or a duration in
+// whole seconds.
+func validateWholeSeconds(s string) error {
+	if s == "" {
+		return nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 || d%time.Second != 0 {
+		return errors.New("must be a duration in whole seconds, such as 15m or 30s")
+	}
+	return nil
+}
+
+// validateSeed returns an error if s isn't a seed that can be replayed.
+func validateSeed(s string) error {
+	if _, err := strconv.ParseInt(s, 10, 64); s != "" && err != nil {
+		return errors.New("the seed must be a whole number")
+	}
+	return nil
+}
+
+func (g *gui) handlePauseButtonTap() {
+	g.pauseButton.Disable()
+	g.startResumeButton.Enable()
+	g.application.handleTimerPause()
+}
+
+func (g *gui) handleResumeButtonTap() {
+	g.pauseButton.Enable()
+	g.application.handleTimerResume()
+}
+
+func (g *gui) handleSkipButtonTap() {
+	g.application.handleTimerSkip()
+}
+
+func (g *gui) handleStopButtonTap() {
+	if g.application.DismissAlarm() {
+		return
+	}
+	g.application.handleTimerCancel()
+	g.reset()
+}
+
+// showSoundEditor shows a dialog for adjusting the trim and fades of the
+// sound registered as name against its waveform. The trim is only applied if
+// it is saved.
+func (g *gui) showSoundEditor(name string) {
+	sound, exists := g.application.sounds[name]
+	if !exists || sound.length() == 0 {
+		return
+	}
+	length := sound.length()
+	trim := sound.trim()
+	if trim.End == 0 {
+		trim.End = length
+	}
+	// a trim ending at the end of the sound is saved as zero so that it
+	// still ends there if the sound's length changes
+	edited := func() SoundTrim {
+		t := trim
+		if t.End == length {
+			t.End = 0
+		}
+		return t
+	}
+
+	peaks := waveformPeaks(sound.buffer, WAVEFORM_BUCKETS)
+	waveform := canvas.NewRasterWithPixels(func(x, y, w, h int) color.Color {
+		at := time.Duration(int64(length) * int64(x) / int64(w))
+		inside := at >= trim.Start && at <= trim.End
+		peak := peaks[x*len(peaks)/w]
+		if inside {
+			peak *= fadeGain(int(at-trim.Start), int(trim.End-trim.Start), int(trim.FadeIn), int(trim.FadeOut))
+		}
+		if extent := peak * float64(h) / 2; math.Abs(float64(y-h/2)) > extent {
+			if inside {
+				return color.White
+			}
+			return color.Gray16{0xdddd}
+		}
+		if inside {
+			return theme.PrimaryColor()
+		}
+		return color.Gray16{0x8888}
+	})
+	waveform.SetMinSize(fyne.NewSize(float32(WAVEFORM_BUCKETS), 100))
+
+	form := container.New(layout.NewGridLayout(2))
+	sliders := []*widget.Slider{}
+	addSlider := func(text string, value *time.Duration) {
+		label := widget.NewLabel("")
+		slider := widget.NewSlider(0, float64(length/time.Millisecond))
+		slider.OnChanged = func(v float64) {
+			*value = time.Duration(v) * time.Millisecond
+			label.SetText(fmt.Sprintf("%s %.3fs", text, value.Seconds()))
+			waveform.Refresh()
+		}
+		slider.SetValue(float64(*value / time.Millisecond))
+		slider.OnChanged(slider.Value)
+		sliders = append(sliders, slider)
+		form.Add(label)
+		form.Add(slider)
+	}
+	addSlider("Start", &trim.Start)
+	addSlider("End", &trim.End)
+	addSlider("Fade in", &trim.FadeIn)
+	addSlider("Fade out", &trim.FadeOut)
+
+	preview := widget.NewButtonWithIcon("Preview", theme.MediaPlayIcon(), func() {
+		stream := *sound
+		if err := stream.setTrim(edited()); err != nil {
+			dialog.ShowError(err, g.window)
+			return
+		}
+		g.application.previewSound(stream)
+	})
+	reset := widget.NewButton("Reset", func() {
+		for i, v := range []time.Duration{0, length, 0, 0} {
+			sliders[i].SetValue(float64(v / time.Millisecond))
+		}
+	})
+
+	content := container.NewVBox(waveform, form, container.NewHBox(preview, reset))
+	d := dialog.NewCustomConfirm("Edit "+name, "Save", "Cancel", content, func(save bool) {
+		if !save {
+			return
+		}
+		if err := g.application.SetSoundTrim(name, edited()); err != nil {
+			dialog.ShowError(err, g.window)
+		}
+	}, g.window)
+	d.Show()
+}
+
+// showSoundManager shows a dialog for importing, renaming, deleting and
+// previewing the sounds in the sound library.
+func (g *gui) showSoundManager() {
+	names := g.application.LibrarySounds()
+	selected := ""
+	list := widget.NewList(
+		func() int { return len(names) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(i widget.ListItemID, o fyne.CanvasObject) { o.(*widget.Label).SetText(names[i]) },
+	)
+
+	importButton := widget.NewButtonWithIcon("Import", theme.ContentAddIcon(), nil)
+	renameButton := widget.NewButton("Rename", nil)
+	deleteButton := widget.NewButtonWithIcon("Delete", theme.DeleteIcon(), nil)
+	previewButton := widget.NewButtonWithIcon("Preview", theme.MediaPlayIcon(), nil)
+	selectionButtons := []*widget.Button{renameButton, deleteButton, previewButton}
+	setSelected := func(name string) {
+		selected = name
+		for _, b := range selectionButtons {
+			if name == "" {
+				b.Disable()
+			} else {
+				b.Enable()
+			}
+		}
+	}
+	setSelected("")
+	list.OnSelected = func(i widget.ListItemID) { setSelected(names[i]) }
+	list.OnUnselected = func(widget.ListItemID) { setSelected("") }
+	refresh := func() {
+		names = g.application.LibrarySounds()
+		list.UnselectAll()
+		list.Refresh()
+		g.refreshSoundOptions()
+	}
+
+	importButton.OnTapped = func() {
+		open := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil {
+				dialog.ShowError(err, g.window)
+				return
+			}
+			if reader == nil {
+				return
+			}
+			reader.Close()
+			if err = g.application.ImportSound(reader.URI().Path(), ""); err != nil {
+				dialog.ShowError(err, g.window)
+			}
+			refresh()
+		}, g.window)
+		open.SetFilter(storage.NewExtensionFileFilter(AUDIO_FILE_EXTENSIONS))
+		open.Show()
+	}
+	renameButton.OnTapped = func() {
+		from := selected
+		entry := widget.NewEntry()
+		entry.SetText(from)
+		dialog.ShowForm("Rename "+from, "Rename", "Cancel", []*widget.FormItem{widget.NewFormItem("Name", entry)}, func(rename bool) {
+			if !rename || entry.Text == from {
+				return
+			}
+			if err := g.application.RenameSound(from, entry.Text); err != nil {
+				dialog.ShowError(err, g.window)
+				return
+			}
+			if g.sounds.Selected == from {
+				g.sounds.Selected = entry.Text
+			}
+			refresh()
+		}, g.window)
+	}
+	deleteButton.OnTapped = func() {
+		name := selected
+		dialog.ShowConfirm("Delete "+name, fmt.Sprintf("Delete %q from the sound library?", name), func(remove bool) {
+			if !remove {
+				return
+			}
+			if err := g.application.DeleteSound(name); err != nil {
+				dialog.ShowError(err, g.window)
+			}
+			refresh()
+		}, g.window)
+	}
+	previewButton.OnTapped = func() {
+		g.application.PreviewSound(selected)
+	}
+
+	buttons := container.NewHBox(importButton, renameButton, deleteButton, previewButton)
+	d := dialog.NewCustom("Sound library", "Close", container.NewBorder(nil, buttons, nil, nil, list), g.window)
+	d.Resize(fyne.NewSize(400, 400))
+	d.Show()
+}
+
+// refreshSoundOptions updates the sound select after sounds are added,
+// renamed or removed, selecting "None" if the selected sound no longer
+// exists.
+func (g *gui) refreshSoundOptions() {
+	g.sounds.Options = g.application.soundOptions()
+	if _, exists := g.application.sounds[g.sounds.Selected]; !exists {
+		g.sounds.SetSelected("None")
+	}
+	g.sounds.Refresh()
+}
+
+// showLoadErrors lists errors that occurred while loading sounds, music and
+// voice packs.
+func (g *gui) showLoadErrors(errs []error) {
+	messages := container.NewVBox()
+	for _, err := range errs {
+		message := widget.NewLabel(err.Error())
+		message.Wrapping = fyne.TextWrapWord
+		messages.Add(message)
+	}
+	content := container.NewBorder(widget.NewLabel("Some sounds couldn't be loaded:"), nil, nil, nil, container.NewVScroll(messages))
+	d := dialog.NewCustom("Load errors", "OK", content, g.window)
+	d.Resize(fyne.NewSize(400, 300))
+	d.Show()
+}
+
+// showAlarm shows a dialog for dismissing or snoozing the ringing alarm.
+// Closing the dialog dismisses the alarm.
+func (g *gui) showAlarm() {
+	g.hideAlarm()
+	snooze := widget.NewButton(fmt.Sprintf("Snooze %s", g.application.AlarmConfig().Snooze), func() {
+		g.application.SnoozeAlarm()
+	})
+	d := dialog.NewCustom("Time's up", "Dismiss", snooze, g.window)
+	d.SetOnClosed(func() {
+		// hideAlarm clears alarmDialog first so closing the dialog from
+		// the application doesn't dismiss the alarm again
+		if g.alarmDialog == d {
+			g.alarmDialog = nil
+			g.application.DismissAlarm()
+		}
+	})
+	g.alarmDialog = d
+	d.Show()
+}
+
+// hideAlarm hides the alarm dialog if it is showing.
+func (g *gui) hideAlarm() {
+	d := g.alarmDialog
+	if d == nil {
+		return
+	}
+	g.alarmDialog = nil
+	d.Hide()
+}
+
+// updateSnooze shows the time remaining until a snoozed alarm sounds again.
+// The stop button dismisses the snoozed alarm.
+func (g *gui) updateSnooze(remaining time.Duration) {
+	g.updateTimerName("Snoozed")
+	secs := int64(remaining / time.Second)
+	g.updateTimerDisplay(fmt.Sprintf("%02d:%02d", secs/60, secs%60))
+	g.stopButton.Enable()
+}
+
+func (g *gui) handleWorkMusicButtonTap() {
+	g.chooseMusicFolder(func(music *MusicConfig, dir string) { music.WorkDir = dir })
+}
+
+func (g *gui) handleRestMusicButtonTap() {
+	g.chooseMusicFolder(func(music *MusicConfig, dir string) { music.RestDir = dir })
+}
+
+func (g *gui) handleClearWorkMusicButtonTap() {
+	g.updateMusic(func(music *MusicConfig) { music.WorkDir = "" })
+}
+
+func (g *gui) handleClearRestMusicButtonTap() {
+	g.updateMusic(func(music *MusicConfig) { music.RestDir = "" })
+}
+
+func (g *gui) handleShuffleMusicChecked(checked bool) {
+	if checked == g.application.MusicConfig().Shuffle {
+		return
+	}
+	g.updateMusic(func(music *MusicConfig) { music.Shuffle = checked })
+}
+
+// chooseMusicFolder shows a folder picker and sets the chosen folder using
+// set.
+func (g *gui) chooseMusicFolder(set func(music *MusicConfig, dir string)) {
+	dialog.ShowFolderOpen(func(uri fyne.ListableURI, err error) {
+		if err != nil {
+			dialog.ShowError(err, g.window)
+			return
+		}
+		if uri == nil {
+			return
+		}
+		g.updateMusic(func(music *MusicConfig) { set(music, uri.Path()) })
+	}, g.window)
+}
+
+// updateMusic applies update to the application's music configuration and
+// refreshes the music settings, showing any errors loading the music.
+func (g *gui) updateMusic(update func(music *MusicConfig)) {
+	music := g.application.MusicConfig()
+	update(&music)
+	if err := g.application.SetMusicConfig(music); err != nil {
+		dialog.ShowError(err, g.window)
+	}
+	g.workMusic.SetText(musicButtonText(music.WorkDir))
+	g.restMusic.SetText(musicButtonText(music.RestDir))
+}
+
+func (g *gui) setMusicEnabled(enabled bool) {
+	for _, w := range []fyne.Disableable{g.workMusic, g.restMusic, g.clearWorkMusic, g.clearRestMusic, g.shuffleMusic} {
+		if enabled {
+			w.Enable()
+		} else {
+			w.Disable()
+		}
+	}
+}
+
+// musicButtonText returns the text of a music folder button for dir.
+func musicButtonText(dir string) string {
+	if dir == "" {
+		return "None"
+	}
+	return filepath.Base(dir)
+}
+
+// newBPMEntry returns an entry for a metronome tempo that accepts only
+// positive numbers, or nothing to turn the metronome off.
+func (g *gui) newBPMEntry(placeHolder string, onChanged func(string)) *widget.Entry {
+	entry := widget.NewEntry()
+	entry.SetPlaceHolder(placeHolder)
+	entry.Validator = func(s string) error {
+		if s != "" && parseBPM(s) == 0 {
+			return errors.New("tempo must be a positive number")
+		}
+		return nil
+	}
+	entry.OnChanged = onChanged
+	return entry
+}
+
+// parseBPM parses a metronome tempo, returning zero if it is invalid.
+func parseBPM(s string) float64 {
+	bpm, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || bpm <= 0 {
+		return 0
+	}
+	return bpm
+}
+
+func (g gui) newCenteredText(text string, color color.Color) *canvas.Text {
+	newText := canvas.NewText(text, color)
+	newText.Alignment = fyne.TextAlignCenter
+	return newText
+}
@@ -0,0 +1,71 @@
+package timer
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gabriel-ross/timer-go/internal"
+	"github.com/gabriel-ross/timer-go/preset"
+)
+
+// Name of the file runs are recorded in, in the app's storage root
+var HISTORY_FILE = "history.json"
+
+// Number of runs kept in the history, the oldest are dropped first
+var MAX_HISTORY_RUNS = 1000
+
+// History returns the runs of the timer recorded in the history file.
+func (a *application) History() (*preset.History, error) {
+	h, err := preset.LoadHistoryFile(a.historyFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return preset.NewHistory(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("loading history: %w", err)
+	}
+	return h, nil
+}
+
+// upgradeHistory rewrites the history file at the current version if it was
+// written with an older version of the history schema.
+func (a *application) upgradeHistory() error {
+	if _, err := os.Stat(a.historyFile); errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	report, err := preset.MigrateFile(a.historyFile, false)
+	if err != nil {
+		return fmt.Errorf("upgrading history: %w", err)
+	}
+	if report.Changed() {
+		log.Printf("history: %v\n", report)
+	}
+	return nil
+}
+
+// recordRun adds the run of t that started at started to the history file,
+// with the settings it ran.
+func (a *application) recordRun(started time.Time, t *internal.RepeatTimer) {
+	h, err := a.History()
+	if err != nil {
+		log.Printf("error recording run: %v\n", err)
+		return
+	}
+	h.Add(preset.Run{
+		Started:  started.Round(0),
+		Elapsed:  preset.Duration(t.Elapsed().Truncate(time.Second)),
+		Finished: !t.Cancelled(),
+		Preset:   *a.Preset(a.LastPreset()),
+	}, MAX_HISTORY_RUNS)
+	if err = os.MkdirAll(filepath.Dir(a.historyFile), 0755); err != nil {
+		log.Printf("error recording run: %v\n", err)
+		return
+	}
+	if err = h.SaveFile(a.historyFile); err != nil {
+		log.Printf("error recording run: %v\n", err)
+	}
+}
@@ -0,0 +1,31 @@
+package timer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpgradeHistory(t *testing.T) {
+	a := &application{historyFile: filepath.Join(t.TempDir(), HISTORY_FILE)}
+	h, err := a.History()
+	require.NoError(t, err)
+	assert.Empty(t, h.Runs)
+	require.NoError(t, a.upgradeHistory())
+
+	old := `{"version": 1, "runs": [{"started": "2024-03-09T18:00:00Z", "elapsed": "10m", "preset": {"version": 1, "program": {"intervals": 2, "work": "20s"}, "cues": {"alarm": true}}}]}`
+	require.NoError(t, os.WriteFile(a.historyFile, []byte(old), 0644))
+	require.NoError(t, a.upgradeHistory())
+	h, err = a.History()
+	require.NoError(t, err)
+	require.Len(t, h.Runs, 1)
+	assert.True(t, h.Runs[0].Preset.Cues.Alarm.Enabled)
+
+	require.NoError(t, os.WriteFile(a.historyFile, []byte(`{"version": 1, "runs": "none"}`), 0644))
+	assert.Error(t, a.upgradeHistory())
+	_, err = a.History()
+	assert.Error(t, err)
+}
@@ -0,0 +1,82 @@
+package internal
+
+import "time"
+
+// AnnouncementKind is the kind of a spoken Announcement.
+type AnnouncementKind int
+
+const (
+	// CountdownAnnouncement counts down the last seconds of an interval.
+	CountdownAnnouncement AnnouncementKind = iota
+	// HalfwayAnnouncement marks the middle of an interval.
+	HalfwayAnnouncement
+	// WorkAnnouncement marks the start of a work interval.
+	WorkAnnouncement
+	// RestAnnouncement marks the start of a rest interval.
+	RestAnnouncement
+)
+
+// Announcement is a cue to be spoken at a point in an interval.
+type Announcement struct {
+	Kind AnnouncementKind
+	// Number is the seconds remaining for a CountdownAnnouncement and the
+	// interval number for a WorkAnnouncement.
+	Number int
+	// Due is when the announcement should be heard.
+	Due time.Time
+}
+
+// announcementEvents returns the scheduled events for the announcements in
+// an interval of phase and the given length. interval is the number of a
+// work interval.
+func (t *RepeatTimer) announcementEvents(phase Phase, interval int, length time.Duration) []scheduledEvent {
+	events := []scheduledEvent{}
+	announce := func(at time.Duration, kind AnnouncementKind, number int) {
+		events = append(events, scheduledEvent{
+			at: at,
+			send: func(due time.Time) {
+				writeAnnouncementChannel(t.announcementC, Announcement{Kind: kind, Number: number, Due: due})
+			},
+		})
+	}
+
+	if t.cnf.AnnounceIntervals {
+		if phase == RestPhase {
+			announce(0, RestAnnouncement, 0)
+		} else {
+			announce(0, WorkAnnouncement, interval)
+		}
+	}
+
+	countdown := time.Duration(t.cnf.CountdownFrom) * time.Second
+	// Skip halfway if it would be spoken over the countdown
+	if t.cnf.AnnounceHalfway && length/2 > countdown {
+		announce(length/2, HalfwayAnnouncement, 0)
+	}
+
+	for n := t.cnf.CountdownFrom; n > 0; n-- {
+		at := length - time.Duration(n)*time.Second
+		if at <= 0 {
+			continue
+		}
+		announce(at, CountdownAnnouncement, n)
+	}
+	return events
+}
+
+func writeAnnouncementChannel(ch chan<- Announcement, out Announcement) {
+	select {
+	case ch <- out:
+	default:
+	}
+}
+
+func drainAnnouncementChannel(ch chan Announcement) {
+	for {
+		select {
+		case <-ch:
+		default:
+			return
+		}
+	}
+}
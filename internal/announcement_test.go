@@ -0,0 +1,55 @@
+package internal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnnouncementEvents(t *testing.T) {
+	timer := NewRepeatCountdownTimer(Config{
+		CountdownFrom:     3,
+		AnnounceHalfway:   true,
+		AnnounceIntervals: true,
+	})
+
+	events := timer.announcementEvents(WorkPhase, 2, 10*time.Second)
+	due := time.Now()
+	for _, event := range events {
+		event.send(due)
+	}
+
+	expected := []struct {
+		at           time.Duration
+		announcement Announcement
+	}{
+		{0, Announcement{Kind: WorkAnnouncement, Number: 2, Due: due}},
+		{5 * time.Second, Announcement{Kind: HalfwayAnnouncement, Due: due}},
+		{7 * time.Second, Announcement{Kind: CountdownAnnouncement, Number: 3, Due: due}},
+		{8 * time.Second, Announcement{Kind: CountdownAnnouncement, Number: 2, Due: due}},
+		{9 * time.Second, Announcement{Kind: CountdownAnnouncement, Number: 1, Due: due}},
+	}
+	if assert.Len(t, events, len(expected)) {
+		for i := range expected {
+			assert.Equal(t, expected[i].at, events[i].at)
+			assert.Equal(t, expected[i].announcement, <-timer.Announcements())
+		}
+	}
+}
+
+func TestAnnouncementEventsShortInterval(t *testing.T) {
+	timer := NewRepeatCountdownTimer(Config{
+		CountdownFrom:     3,
+		AnnounceHalfway:   true,
+		AnnounceIntervals: true,
+	})
+
+	// halfway falls within the countdown and the countdown can't start at 3
+	events := timer.announcementEvents(RestPhase, 0, 2*time.Second)
+	ats := []time.Duration{}
+	for _, event := range events {
+		ats = append(ats, event.at)
+	}
+	assert.Equal(t, []time.Duration{0, time.Second}, ats)
+}
@@ -0,0 +1,81 @@
+package internal
+
+import (
+	"sync"
+	"time"
+)
+
+// clock measures the time a timer has been running for, excluding any time
+// spent paused. It is safe for concurrent use.
+type clock struct {
+	mu       sync.Mutex
+	now      func() time.Time
+	started  time.Time
+	pausedAt time.Time
+	paused   time.Duration
+	running  bool
+	isPaused bool
+}
+
+func newClock(now func() time.Time) *clock {
+	return &clock{
+		now: now,
+	}
+}
+
+// start resets the clock and starts it running.
+func (c *clock) start() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.started = c.now()
+	c.paused = 0
+	c.running = true
+	c.isPaused = false
+}
+
+// stop stops the clock at its current elapsed time.
+func (c *clock) stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.running {
+		return
+	}
+	if !c.isPaused {
+		c.pausedAt = c.now()
+		c.isPaused = true
+	}
+	c.running = false
+}
+
+func (c *clock) pause() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.running && !c.isPaused {
+		c.pausedAt = c.now()
+		c.isPaused = true
+	}
+}
+
+func (c *clock) resume() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.running && c.isPaused {
+		c.paused += c.now().Sub(c.pausedAt)
+		c.isPaused = false
+	}
+}
+
+// elapsed returns the time the clock has been running for, excluding time
+// spent paused.
+func (c *clock) elapsed() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.started.IsZero() {
+		return 0
+	}
+	end := c.now()
+	if c.isPaused {
+		end = c.pausedAt
+	}
+	return end.Sub(c.started) - c.paused
+}
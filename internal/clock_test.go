@@ -0,0 +1,34 @@
+package internal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClock(t *testing.T) {
+	now := time.Unix(0, 0)
+	c := newClock(func() time.Time { return now })
+	assert.Zero(t, c.elapsed())
+
+	c.start()
+	now = now.Add(3 * time.Second)
+	assert.Equal(t, 3*time.Second, c.elapsed())
+
+	c.pause()
+	now = now.Add(10 * time.Second)
+	assert.Equal(t, 3*time.Second, c.elapsed())
+
+	c.resume()
+	now = now.Add(2 * time.Second)
+	assert.Equal(t, 5*time.Second, c.elapsed())
+
+	c.stop()
+	now = now.Add(time.Minute)
+	assert.Equal(t, 5*time.Second, c.elapsed())
+
+	c.start()
+	now = now.Add(time.Second)
+	assert.Equal(t, time.Second, c.elapsed())
+}
@@ -1,237 +1,713 @@
-package internal
-
-import (
-	"fmt"
-	"strings"
-	"time"
-)
-
-type Config struct {
-	Intervals       int
-	IntervalMinutes int64
-	IntervalSeconds int64
-	RestEnabled     bool
-	RestMinutes     int64
-	RestSeconds     int64
-	RestBeforeStart bool
-}
-
-type RepeatTimer struct {
-	cnf               Config
-	shouldRest        bool
-	cancel            bool
-	intervalNameC     chan string
-	timeRemainingC    chan string
-	intervalFinishedC chan bool
-	*countdownTimer
-}
-
-func NewRepeatCountdownTimer(cnf Config) *RepeatTimer {
-	// Format interval and rest seconds so that they don't exceed 59. Add
-	// overflow to minutes
-	for cnf.IntervalSeconds > 59 {
-		cnf.IntervalMinutes++
-		cnf.IntervalSeconds -= 59
-	}
-	for cnf.RestSeconds > 59 {
-		cnf.RestMinutes++
-		cnf.RestSeconds -= 59
-	}
-
-	return &RepeatTimer{
-		cnf:               cnf,
-		shouldRest:        cnf.RestBeforeStart,
-		cancel:            false,
-		intervalNameC:     make(chan string, 100),
-		timeRemainingC:    make(chan string, 100),
-		intervalFinishedC: make(chan bool),
-		countdownTimer:    newCountdownTimer(),
-	}
-}
-
-func (t *RepeatTimer) Start() {
-	t.reset()
-	writeStringChannel(t.intervalNameC, "Starting")
-
-	if t.cnf.RestBeforeStart {
-		writeStringChannel(t.intervalNameC, "Rest")
-		t.countdownTimer.runInterval(t.timeRemainingC, t.cnf.RestMinutes, t.cnf.RestSeconds)
-		writeBoolChannel(t.intervalFinishedC)
-	}
-
-	interval := 1
-	for interval <= t.cnf.Intervals && !t.cancel {
-		if t.shouldRest {
-			writeStringChannel(t.intervalNameC, "Rest")
-			t.countdownTimer.runInterval(t.timeRemainingC, t.cnf.RestMinutes, t.cnf.RestSeconds)
-			writeBoolChannel(t.intervalFinishedC)
-		} else {
-			writeStringChannel(t.intervalNameC, fmt.Sprintf("Interval %d/%d", interval, t.cnf.Intervals))
-			t.countdownTimer.runInterval(t.timeRemainingC, t.cnf.IntervalMinutes, t.cnf.IntervalSeconds)
-			writeBoolChannel(t.intervalFinishedC)
-			interval++
-		}
-		t.shouldRest = !t.shouldRest
-	}
-}
-
-// reset resets all RepeatTimer flags and clears all channels.
-func (t *RepeatTimer) reset() {
-	t.cancel = false
-	t.shouldRest = false
-	t.intervalNameC = make(chan string, 100)
-	t.timeRemainingC = make(chan string, 100)
-	t.intervalFinishedC = make(chan bool)
-	t.countdownTimer = newCountdownTimer()
-}
-
-func (t *RepeatTimer) IntervalName() <-chan string {
-	return t.intervalNameC
-}
-
-func (t *RepeatTimer) TimeRemaining() <-chan string {
-	return t.timeRemainingC
-}
-
-func (t *RepeatTimer) IntervalFinished() <-chan bool {
-	return t.intervalFinishedC
-}
-
-// Cancel cancels the timer and writes zero time remaining to the
-// time remaining channel.
-func (t *RepeatTimer) Cancel() {
-	t.cancel = true
-	t.countdownTimer.cancel()
-	writeStringChannel(t.timeRemainingC, "00:00")
-}
-
-// Skip skips the current interval.
-func (t *RepeatTimer) Skip() {
-	t.countdownTimer.cancel()
-}
-
-// RestartInterval restarts the current interval.
-func (t *RepeatTimer) RestartInterval() {
-	t.countdownTimer.restart()
-}
-
-type countdownTimer struct {
-	running  bool
-	cancelC  chan bool
-	pauseC   chan bool
-	resumeC  chan bool
-	restartC chan bool
-}
-
-func newCountdownTimer() *countdownTimer {
-	return &countdownTimer{
-		running:  false,
-		cancelC:  make(chan bool),
-		pauseC:   make(chan bool),
-		resumeC:  make(chan bool),
-		restartC: make(chan bool),
-	}
-}
-
-func (c *countdownTimer) runInterval(remainingC chan<- string, mins, secs int64) {
-	c.running = true
-	writeStringChannel(remainingC, formatTimeRemaining(mins, secs))
-
-	// Decrement time before first tick, otherwise countdown is one second
-	// longer than intended
-	var remainingMins, remainingSecs int64
-	switch {
-	case secs > 0:
-		remainingMins, remainingSecs = mins, secs-1
-	case secs == 0 && mins > 0:
-		remainingMins, remainingSecs = mins-1, 59
-	default: // if minutes and seconds are both zero
-		c.running = false
-		return
-	}
-
-	ticker := time.NewTicker(time.Second)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-c.pauseC:
-			c.running = false
-			select {
-			case <-c.resumeC:
-				c.running = true
-			case <-c.cancelC:
-				c.running = false
-				return
-			}
-		case <-c.cancelC:
-			c.running = false
-			return
-		case <-c.restartC:
-			remainingMins = mins
-			remainingSecs = secs
-		case <-ticker.C:
-			writeStringChannel(remainingC, formatTimeRemaining(remainingMins, remainingSecs))
-			switch {
-			case remainingMins > 0 && remainingSecs == 0:
-				remainingMins--
-				remainingSecs = 59
-			case remainingSecs > 0:
-				remainingSecs--
-			case remainingMins == 0 && remainingSecs == 0:
-				c.running = false
-				return
-			}
-		}
-	}
-}
-
-func formatTimeRemaining(mins, secs int64) string {
-	var builder strings.Builder
-	if mins < 10 {
-		builder.WriteString("0")
-	}
-	builder.WriteString(fmt.Sprintf("%d:", mins))
-	if secs < 10 {
-		builder.WriteString("0")
-	}
-	builder.WriteString(fmt.Sprintf("%d", secs))
-	return builder.String()
-}
-
-func (c *countdownTimer) cancel() {
-	c.cancelC <- true
-}
-
-func (c *countdownTimer) Pause() {
-	if c.running {
-		c.pauseC <- true
-	}
-}
-
-func (c *countdownTimer) Resume() {
-	if !c.running {
-		c.resumeC <- true
-	}
-}
-
-func (c *countdownTimer) restart() {
-	c.restartC <- true
-}
-
-// writeStringChannel is a non-blocking helper function for writing outputs to channels.
-// It attempts to write to the specified channel, but skips the write if the
-// channel is full
-func writeStringChannel(ch chan<- string, out string) {
-	select {
-	case ch <- out:
-	default:
-	}
-}
-
-func writeBoolChannel(ch chan<- bool) {
-	select {
-	case ch <- true:
-	default:
-	}
-}
+package internal
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type Config struct {
+	Intervals       int
+	IntervalMinutes int64
+	IntervalSeconds int64
+	RestEnabled     bool
+	RestMinutes     int64
+	RestSeconds     int64
+	RestBeforeStart bool
+	CueLead         time.Duration // How far ahead of the end of each interval and each beat to send its cue
+	WorkMetronome   Metronome
+	RestMetronome   Metronome
+
+	CountdownFrom     int  // Announce the last seconds of each interval counting down from this number, 0 disables
+	AnnounceHalfway   bool // Announce the middle of each interval
+	AnnounceIntervals bool // Announce the start of each work and rest interval
+
+	// Segments is the program to run instead of Intervals work intervals
+	// separated by rests. Each segment runs once, in order.
+	Segments []Segment
+	Seed     int64 // Seeds the lengths of randomized segments so that a run can be repeated exactly
+
+	// Tracks run alongside the program, such as an overall time cap.
+	Tracks []Track
+
+	// Repeat runs the program again each time it finishes until the timer
+	// is cancelled or, if Until is set, until that time of day on the wall
+	// clock. Each run through the program is a round. A round of the
+	// interval settings is a single work interval, ignoring Intervals.
+	Repeat bool
+	Until  time.Time
+}
+
+// Segment is a single timed step of a program.
+type Segment struct {
+	Name     string        // Shown while the segment runs, defaults to the interval number for work and "Rest" for rest
+	Phase    Phase         // Whether the segment is work or rest
+	Duration time.Duration // Length of the segment, rounded down to whole seconds
+
+	// MaxDuration randomizes the length of the segment when set. The length
+	// is chosen when the segment starts, uniformly between Duration and
+	// MaxDuration in whole seconds.
+	MaxDuration time.Duration
+
+	// Part names the part of a session the segment belongs to, such as the
+	// preset it came from when several are run back to back.
+	Part string
+
+	// Metronome is played through the segment in place of the metronome
+	// for its phase when set. A zero StartBPM silences it.
+	Metronome *Metronome
+}
+
+// length returns the length of the segment, choosing it with rng if it is
+// randomized.
+func (s Segment) length(rng *rand.Rand) time.Duration {
+	min, max := s.bounds()
+	if max <= min {
+		return min
+	}
+	return min + time.Duration(rng.Int63n(int64((max-min)/time.Second)+1))*time.Second
+}
+
+// bounds returns the shortest and longest the segment can be.
+func (s Segment) bounds() (time.Duration, time.Duration) {
+	min, max := s.Duration.Truncate(time.Second), s.MaxDuration.Truncate(time.Second)
+	if max <= min {
+		return min, min
+	}
+	return min, max
+}
+
+// program returns the segments run by a timer with the config.
+func (cnf Config) program() []Segment {
+	if len(cnf.Segments) > 0 {
+		return cnf.Segments
+	}
+	rest := Segment{
+		Phase:    RestPhase,
+		Duration: time.Duration(cnf.RestMinutes)*time.Minute + time.Duration(cnf.RestSeconds)*time.Second,
+	}
+	work := Segment{
+		Phase:    WorkPhase,
+		Duration: time.Duration(cnf.IntervalMinutes)*time.Minute + time.Duration(cnf.IntervalSeconds)*time.Second,
+	}
+	segments := []Segment{}
+	if cnf.RestBeforeStart {
+		segments = append(segments, rest)
+	}
+	for interval := 1; interval <= cnf.Intervals; interval++ {
+		if interval > 1 {
+			segments = append(segments, rest)
+		}
+		segments = append(segments, work)
+	}
+	return segments
+}
+
+// round returns the segments run in round n, counting from 1. A round of
+// the interval settings of a repeating timer is a single work interval,
+// after a rest unless it is the first round and RestBeforeStart is false.
+func (cnf Config) round(n int) []Segment {
+	if len(cnf.Segments) > 0 || !cnf.Repeat {
+		return cnf.program()
+	}
+	single := cnf
+	single.Intervals = 1
+	single.RestBeforeStart = cnf.RestBeforeStart || n > 1
+	return single.program()
+}
+
+// Phase is the kind of interval a RepeatTimer is running.
+type Phase int
+
+const (
+	WorkPhase Phase = iota
+	RestPhase
+)
+
+type RepeatTimer struct {
+	cnf               Config
+	mu                sync.Mutex // Orders Cancel and the end of a run with the reset at the start of the next
+	cancel            atomic.Bool
+	stopped           bool          // Whether stopC is closed, guarded by mu
+	stopC             chan struct{} // Closed when the current run is cancelled or ends
+	intervalNameC     chan string
+	timeRemainingC    chan string
+	intervalFinishedC chan bool
+	intervalEndingC   chan time.Time
+	phaseC            chan Phase
+	beatC             chan time.Time
+	announcementC     chan Announcement
+	trackC            chan TrackUpdate
+	endedBy           atomic.Int32 // Index+1 of the track that ended the run, 0 if none
+	reachedUntil      atomic.Bool
+	progress          *progressTracker
+	clock             *clock
+	*countdownTimer
+}
+
+func NewRepeatCountdownTimer(cnf Config) *RepeatTimer {
+	// Format interval and rest seconds so that they don't exceed 59. Add
+	// overflow to minutes
+	for cnf.IntervalSeconds > 59 {
+		cnf.IntervalMinutes++
+		cnf.IntervalSeconds -= 59
+	}
+	for cnf.RestSeconds > 59 {
+		cnf.RestMinutes++
+		cnf.RestSeconds -= 59
+	}
+
+	clock := newClock(time.Now)
+	// there is no run until Start, so the stop channel starts closed
+	stopC := make(chan struct{})
+	close(stopC)
+	return &RepeatTimer{
+		cnf:               cnf,
+		stopped:           true,
+		stopC:             stopC,
+		intervalNameC:     make(chan string, 100),
+		timeRemainingC:    make(chan string, 100),
+		intervalFinishedC: make(chan bool, 100),
+		intervalEndingC:   make(chan time.Time, 100),
+		phaseC:            make(chan Phase, 100),
+		beatC:             make(chan time.Time, 100),
+		announcementC:     make(chan Announcement, 100),
+		trackC:            make(chan TrackUpdate, 100),
+		progress:          &progressTracker{clock: clock},
+		clock:             clock,
+		countdownTimer:    newCountdownTimer(),
+	}
+}
+
+// Start runs the timer and blocks until it finishes or is cancelled.
+func (t *RepeatTimer) Start() {
+	t.reset()
+	defer t.stop()
+	t.clock.start()
+	defer t.clock.stop()
+	writeStringChannel(t.intervalNameC, "Starting")
+
+	done := make(chan struct{})
+	var background sync.WaitGroup
+	for i, track := range t.cnf.Tracks {
+		background.Add(1)
+		go func(i int, track Track) {
+			defer background.Done()
+			t.runTrack(i, track, rand.New(rand.NewSource(t.cnf.Seed+int64(i)+1)), done)
+		}(i, track)
+	}
+	if t.cnf.Repeat && !t.cnf.Until.IsZero() {
+		background.Add(1)
+		go func() {
+			defer background.Done()
+			t.runUntil(done)
+		}()
+	}
+	defer background.Wait()
+	defer close(done)
+
+	works := 0
+	if !t.cnf.Repeat {
+		for _, segment := range t.cnf.program() {
+			if segment.Phase == WorkPhase {
+				works++
+			}
+		}
+	}
+	rng := rand.New(rand.NewSource(t.cnf.Seed))
+	interval := 0
+	for round := 1; ; round++ {
+		interval = t.runRound(round, t.cnf.round(round), rng, interval, works)
+		if t.cancel.Load() || t.ended() {
+			return
+		}
+		t.progress.finish()
+		if !t.cnf.Repeat {
+			return
+		}
+	}
+}
+
+// runRound runs the segments of one round of the program. interval is the
+// number of work intervals run before the round and works the number in the
+// whole program, 0 if the program repeats. Returns the number of work
+// intervals run including the round's.
+func (t *RepeatTimer) runRound(round int, program []Segment, rng *rand.Rand, interval, works int) int {
+	// the lengths of randomized segments are chosen as each starts, so only
+	// the range of the round's length is known until then
+	var total, maxTotal time.Duration
+	for _, segment := range program {
+		min, max := segment.bounds()
+		total += min
+		maxTotal += max
+	}
+	t.progress.start(round, len(program), total, maxTotal)
+
+	var offset time.Duration
+	for _, segment := range program {
+		if t.cancel.Load() || t.ended() {
+			break
+		}
+		name := segment.Name
+		if segment.Phase == WorkPhase {
+			interval++
+			switch {
+			case name != "":
+			case works > 0:
+				name = fmt.Sprintf("Interval %d/%d", interval, works)
+			default:
+				name = fmt.Sprintf("Interval %d", interval)
+			}
+		} else if name == "" {
+			name = "Rest"
+		}
+		writePhaseChannel(t.phaseC, segment.Phase)
+		writeStringChannel(t.intervalNameC, name)
+		min, max := segment.bounds()
+		segment.Duration = segment.length(rng)
+		t.progress.next(segment.Part, segment.Duration, offset, segment.Duration-min, max-segment.Duration)
+		offset += segment.Duration
+		mins, secs := int64(segment.Duration/time.Minute), int64(segment.Duration%time.Minute/time.Second)
+		t.countdownTimer.runInterval(t.timeRemainingC, t.stopC, t.newSchedule(segment, interval), mins, secs)
+		t.progress.segmentDone(segment.Phase, !t.cancel.Load() && !t.ended())
+		writeBoolChannel(t.intervalFinishedC)
+	}
+	return interval
+}
+
+// runUntil ends a repeating program at Config.Until. The time is checked on
+// the wall clock at least every second, so that it isn't delayed by pauses
+// or by the computer sleeping.
+func (t *RepeatTimer) runUntil(done <-chan struct{}) {
+	for {
+		wait := time.Until(t.cnf.Until.Round(0))
+		if wait <= 0 {
+			break
+		}
+		if wait > time.Second {
+			wait = time.Second
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-done:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+	if t.EndedBy() >= 0 || !t.reachedUntil.CompareAndSwap(false, true) {
+		return
+	}
+	t.stopInterval(done)
+}
+
+// stopInterval stops the running interval, or the next to start, unless the
+// run finishes first.
+func (t *RepeatTimer) stopInterval(done <-chan struct{}) {
+	select {
+	case t.countdownTimer.cancelC <- true:
+	case <-done:
+	}
+}
+
+// newSchedule returns the schedule of cues for segment. interval is the
+// number of the most recent work segment.
+func (t *RepeatTimer) newSchedule(segment Segment, interval int) *intervalSchedule {
+	phase := segment.Phase
+	length := segment.Duration.Truncate(time.Second)
+	metronome := t.cnf.WorkMetronome
+	if phase == RestPhase {
+		metronome = t.cnf.RestMetronome
+	}
+	if segment.Metronome != nil {
+		metronome = *segment.Metronome
+	}
+
+	events := []scheduledEvent{}
+	for _, beat := range metronome.beats(length) {
+		events = append(events, scheduledEvent{at: beat, send: t.sendBeat})
+	}
+	events = append(events, t.announcementEvents(phase, interval, length)...)
+	events = append(events, scheduledEvent{at: length, send: t.sendIntervalEnding, always: true})
+	return newIntervalSchedule(t.cnf.CueLead, events)
+}
+
+func (t *RepeatTimer) sendBeat(due time.Time) {
+	writeTimeChannel(t.beatC, due)
+}
+
+func (t *RepeatTimer) sendIntervalEnding(due time.Time) {
+	writeTimeChannel(t.intervalEndingC, due)
+}
+
+// reset resets all RepeatTimer flags and drains all channels. The channels
+// themselves are kept so that listeners from a previous run keep receiving.
+func (t *RepeatTimer) reset() {
+	t.mu.Lock()
+	t.cancel.Store(false)
+	t.stopped = false
+	t.stopC = make(chan struct{})
+	t.mu.Unlock()
+	drainStringChannel(t.intervalNameC)
+	drainStringChannel(t.timeRemainingC)
+	drainBoolChannel(t.intervalFinishedC)
+	drainTimeChannel(t.intervalEndingC)
+	drainPhaseChannel(t.phaseC)
+	drainTimeChannel(t.beatC)
+	drainAnnouncementChannel(t.announcementC)
+	drainTrackChannel(t.trackC)
+	t.endedBy.Store(0)
+	t.reachedUntil.Store(false)
+}
+
+func (t *RepeatTimer) IntervalName() <-chan string {
+	return t.intervalNameC
+}
+
+func (t *RepeatTimer) TimeRemaining() <-chan string {
+	return t.timeRemainingC
+}
+
+func (t *RepeatTimer) IntervalFinished() <-chan bool {
+	return t.intervalFinishedC
+}
+
+// PhaseStarted receives the phase of each interval as it starts.
+func (t *RepeatTimer) PhaseStarted() <-chan Phase {
+	return t.phaseC
+}
+
+// IntervalEnding receives the time at which each interval is due to end,
+// Config.CueLead ahead of the end of the interval so that cues can be
+// scheduled to line up with it. If an interval ends early, for example
+// because it is skipped, the time it actually ended is sent instead.
+func (t *RepeatTimer) IntervalEnding() <-chan time.Time {
+	return t.intervalEndingC
+}
+
+// Beat receives the time of each metronome beat, Config.CueLead ahead of the
+// beat.
+func (t *RepeatTimer) Beat() <-chan time.Time {
+	return t.beatC
+}
+
+// Announcements receives cues to be spoken, Config.CueLead ahead of when
+// they should be heard.
+func (t *RepeatTimer) Announcements() <-chan Announcement {
+	return t.announcementC
+}
+
+// Tracks receives the state of each of Config.Tracks as it changes.
+func (t *RepeatTimer) Tracks() <-chan TrackUpdate {
+	return t.trackC
+}
+
+// ReachedUntil returns whether the current or most recent run ended because
+// it reached Config.Until.
+func (t *RepeatTimer) ReachedUntil() bool {
+	return t.reachedUntil.Load()
+}
+
+// ended returns whether the run was ended early by a track or by reaching
+// Config.Until.
+func (t *RepeatTimer) ended() bool {
+	return t.EndedBy() >= 0 || t.ReachedUntil()
+}
+
+// Summary returns what the current or most recent run has done so far.
+func (t *RepeatTimer) Summary() Summary {
+	return t.progress.summary()
+}
+
+// EndedBy returns the index in Config.Tracks of the track that ended the
+// current or most recent run early, or -1 if no track ended it.
+func (t *RepeatTimer) EndedBy() int {
+	return int(t.endedBy.Load()) - 1
+}
+
+// Progress returns how far the current or most recent run has got through
+// its program.
+func (t *RepeatTimer) Progress() Progress {
+	return t.progress.get()
+}
+
+// Elapsed returns the time the timer has been running for in its current or
+// most recent run, excluding time spent paused.
+func (t *RepeatTimer) Elapsed() time.Duration {
+	return t.clock.elapsed()
+}
+
+// Pause pauses the timer.
+func (t *RepeatTimer) Pause() {
+	t.countdownTimer.Pause(t.stopChannel())
+	t.clock.pause()
+}
+
+// Resume resumes the timer if it is paused.
+func (t *RepeatTimer) Resume() {
+	t.countdownTimer.Resume(t.stopChannel())
+	t.clock.resume()
+}
+
+// Cancel cancels the timer's current run and writes zero time remaining to
+// the time remaining channel. It doesn't block, even between intervals.
+func (t *RepeatTimer) Cancel() {
+	t.mu.Lock()
+	t.cancel.Store(true)
+	t.closeStop()
+	t.mu.Unlock()
+	writeStringChannel(t.timeRemainingC, "00:00")
+}
+
+// stop closes the current run's stop channel once the run has ended.
+func (t *RepeatTimer) stop() {
+	t.mu.Lock()
+	t.closeStop()
+	t.mu.Unlock()
+}
+
+// closeStop closes stopC if it isn't already. t.mu must be held.
+func (t *RepeatTimer) closeStop() {
+	if !t.stopped {
+		t.stopped = true
+		close(t.stopC)
+	}
+}
+
+// stopChannel returns the channel closed when the current run is cancelled
+// or ends, so that controls sent to the countdown don't block once there is
+// no interval left to receive them.
+func (t *RepeatTimer) stopChannel() <-chan struct{} {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.stopC
+}
+
+// Cancelled returns whether the timer's current or most recent run was
+// cancelled rather than running to completion.
+func (t *RepeatTimer) Cancelled() bool {
+	return t.cancel.Load()
+}
+
+// Skip skips the current interval, or the next to start between intervals.
+// It doesn't block once the run has been cancelled or has ended.
+func (t *RepeatTimer) Skip() {
+	t.countdownTimer.cancel(t.stopChannel())
+}
+
+// RestartInterval restarts the current interval. It doesn't block once the
+// run has been cancelled or has ended.
+func (t *RepeatTimer) RestartInterval() {
+	t.countdownTimer.restart(t.stopChannel())
+}
+
+type countdownTimer struct {
+	running  atomic.Bool
+	cancelC  chan bool
+	pauseC   chan bool
+	resumeC  chan bool
+	restartC chan bool
+}
+
+func newCountdownTimer() *countdownTimer {
+	return &countdownTimer{
+		cancelC:  make(chan bool),
+		pauseC:   make(chan bool),
+		resumeC:  make(chan bool),
+		restartC: make(chan bool),
+	}
+}
+
+// runInterval counts down mins and secs, until the interval is cancelled or
+// stop is closed.
+func (c *countdownTimer) runInterval(remainingC chan<- string, stop <-chan struct{}, schedule *intervalSchedule, mins, secs int64) {
+	c.running.Store(true)
+	writeStringChannel(remainingC, formatTimeRemaining(mins, secs))
+	schedule.start()
+	defer schedule.stop()
+
+	// Decrement time before first tick, otherwise countdown is one second
+	// longer than intended
+	var remainingMins, remainingSecs int64
+	switch {
+	case secs > 0:
+		remainingMins, remainingSecs = mins, secs-1
+	case secs == 0 && mins > 0:
+		remainingMins, remainingSecs = mins-1, 59
+	default: // if minutes and seconds are both zero
+		c.running.Store(false)
+		return
+	}
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.pauseC:
+			c.running.Store(false)
+			schedule.pause()
+			select {
+			case <-c.resumeC:
+				c.running.Store(true)
+				schedule.resume()
+			case <-c.cancelC:
+				c.running.Store(false)
+				return
+			case <-stop:
+				c.running.Store(false)
+				return
+			}
+		case <-c.cancelC:
+			c.running.Store(false)
+			return
+		case <-stop:
+			c.running.Store(false)
+			return
+		case <-c.restartC:
+			remainingMins = mins
+			remainingSecs = secs
+			schedule.start()
+		case <-schedule.C():
+			schedule.fire()
+		case <-ticker.C:
+			writeStringChannel(remainingC, formatTimeRemaining(remainingMins, remainingSecs))
+			switch {
+			case remainingMins > 0 && remainingSecs == 0:
+				remainingMins--
+				remainingSecs = 59
+			case remainingSecs > 0:
+				remainingSecs--
+			case remainingMins == 0 && remainingSecs == 0:
+				c.running.Store(false)
+				return
+			}
+		}
+	}
+}
+
+// stopTimer stops t and drains its channel so that it can be safely reset.
+func stopTimer(t *time.Timer) {
+	if !t.Stop() {
+		select {
+		case <-t.C:
+		default:
+		}
+	}
+}
+
+func formatTimeRemaining(mins, secs int64) string {
+	var builder strings.Builder
+	if mins < 10 {
+		builder.WriteString("0")
+	}
+	builder.WriteString(fmt.Sprintf("%d:", mins))
+	if secs < 10 {
+		builder.WriteString("0")
+	}
+	builder.WriteString(fmt.Sprintf("%d", secs))
+	return builder.String()
+}
+
+// cancel, Pause, Resume and restart send to the running interval, giving up
+// when stop is closed.
+func (c *countdownTimer) cancel(stop <-chan struct{}) {
+	sendControl(c.cancelC, stop)
+}
+
+func (c *countdownTimer) Pause(stop <-chan struct{}) {
+	if c.running.Load() {
+		sendControl(c.pauseC, stop)
+	}
+}
+
+func (c *countdownTimer) Resume(stop <-chan struct{}) {
+	if !c.running.Load() {
+		sendControl(c.resumeC, stop)
+	}
+}
+
+func (c *countdownTimer) restart(stop <-chan struct{}) {
+	sendControl(c.restartC, stop)
+}
+
+func sendControl(ch chan<- bool, stop <-chan struct{}) {
+	select {
+	case ch <- true:
+	case <-stop:
+	}
+}
+
+// writeStringChannel is a non-blocking helper function for writing outputs to channels.
+// It attempts to write to the specified channel, but skips the write if the
+// channel is full
+func writeStringChannel(ch chan<- string, out string) {
+	select {
+	case ch <- out:
+	default:
+	}
+}
+
+func writeBoolChannel(ch chan<- bool) {
+	select {
+	case ch <- true:
+	default:
+	}
+}
+
+func writeTimeChannel(ch chan<- time.Time, out time.Time) {
+	select {
+	case ch <- out:
+	default:
+	}
+}
+
+func writePhaseChannel(ch chan<- Phase, out Phase) {
+	select {
+	case ch <- out:
+	default:
+	}
+}
+
+func drainStringChannel(ch chan string) {
+	for {
+		select {
+		case <-ch:
+		default:
+			return
+		}
+	}
+}
+
+func drainBoolChannel(ch chan bool) {
+	for {
+		select {
+		case <-ch:
+		default:
+			return
+		}
+	}
+}
+
+func drainTimeChannel(ch chan time.Time) {
+	for {
+		select {
+		case <-ch:
+		default:
+			return
+		}
+	}
+}
+
+func drainPhaseChannel(ch chan Phase) {
+	for {
+		select {
+		case <-ch:
+		default:
+			return
+		}
+	}
+}
@@ -1,52 +1,246 @@
-package internal
-
-import (
-	"fmt"
-	"testing"
-	"time"
-
-	"github.com/stretchr/testify/assert"
-)
-
-var PRECISION = 300 * time.Millisecond
-
-func TestRepeatTimer(t *testing.T) {
-	cnf := Config{
-		Intervals:       2,
-		IntervalMinutes: 0,
-		IntervalSeconds: 5,
-		RestEnabled:     true,
-		RestMinutes:     0,
-		RestSeconds:     2,
-		RestBeforeStart: true,
-	}
-	timer := NewRepeatCountdownTimer(cnf)
-	intervalOrder := []string{}
-	done := make(chan bool)
-
-	expectedTime := 14 * time.Second // intervals*IntervalSeconds + Intervals*RestSeconds = 26
-
-	go func() {
-		fmt.Printf("test in progress expected time: %v", expectedTime)
-		for {
-			select {
-			case name := <-timer.IntervalName():
-				intervalOrder = append(intervalOrder, name)
-			case <-done:
-				fmt.Println()
-				return
-			case <-time.Tick(time.Second):
-				fmt.Print(".")
-			}
-		}
-	}()
-
-	startTime := time.Now()
-	timer.Start()
-	done <- true
-	actualTime := time.Since(startTime)
-	diff := (expectedTime - actualTime).Abs()
-
-	assert.LessOrEqualf(t, diff, PRECISION, "actual time %v is not within %v+-%v", actualTime, expectedTime, PRECISION)
-	assert.ElementsMatch(t, intervalOrder, []string{"Starting", "Rest", "Interval 1/2", "Rest", "Interval 2/2"})
-}
+package internal
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var PRECISION = 300 * time.Millisecond
+
+func TestRepeatTimer(t *testing.T) {
+	cnf := Config{
+		Intervals:       2,
+		IntervalMinutes: 0,
+		IntervalSeconds: 5,
+		RestEnabled:     true,
+		RestMinutes:     0,
+		RestSeconds:     2,
+		RestBeforeStart: true,
+	}
+	timer := NewRepeatCountdownTimer(cnf)
+	intervalOrder := []string{}
+	phases := []Phase{}
+	done := make(chan bool)
+
+	expectedTime := 14 * time.Second // intervals*IntervalSeconds + Intervals*RestSeconds = 26
+
+	go func() {
+		fmt.Printf("test in progress expected time: %v", expectedTime)
+		for {
+			select {
+			case name := <-timer.IntervalName():
+				intervalOrder = append(intervalOrder, name)
+			case phase := <-timer.PhaseStarted():
+				phases = append(phases, phase)
+			case <-done:
+				fmt.Println()
+				return
+			case <-time.Tick(time.Second):
+				fmt.Print(".")
+			}
+		}
+	}()
+
+	startTime := time.Now()
+	timer.Start()
+	done <- true
+	actualTime := time.Since(startTime)
+	diff := (expectedTime - actualTime).Abs()
+
+	assert.LessOrEqualf(t, diff, PRECISION, "actual time %v is not within %v+-%v", actualTime, expectedTime, PRECISION)
+	assert.ElementsMatch(t, intervalOrder, []string{"Starting", "Rest", "Interval 1/2", "Rest", "Interval 2/2"})
+	assert.Equal(t, []Phase{RestPhase, WorkPhase, RestPhase, WorkPhase}, phases)
+}
+
+func TestIntervalEndingLead(t *testing.T) {
+	lead := 300 * time.Millisecond
+	timer := NewRepeatCountdownTimer(Config{
+		Intervals:       1,
+		IntervalSeconds: 1,
+		CueLead:         lead,
+	})
+
+	startTime := time.Now()
+	go timer.Start()
+
+	select {
+	case end := <-timer.IntervalEnding():
+		received := time.Since(startTime)
+		assert.InDelta(t, time.Second-lead, received, float64(PRECISION/3), "cue received at %v", received)
+		assert.InDelta(t, time.Second, end.Sub(startTime), float64(PRECISION/3), "cue scheduled for %v", end.Sub(startTime))
+	case <-time.After(2 * time.Second):
+		t.Fatal("no interval ending cue received")
+	}
+}
+
+func TestProgram(t *testing.T) {
+	cnf := Config{
+		Intervals:       3,
+		IntervalSeconds: 20,
+		RestSeconds:     10,
+		RestBeforeStart: true,
+	}
+	work := Segment{Phase: WorkPhase, Duration: 20 * time.Second}
+	rest := Segment{Phase: RestPhase, Duration: 10 * time.Second}
+	assert.Equal(t, []Segment{rest, work, rest, work, rest, work}, cnf.program())
+
+	cnf.Segments = []Segment{{Name: "Prep", Phase: RestPhase, Duration: time.Minute}}
+	assert.Equal(t, cnf.Segments, cnf.program())
+}
+
+func TestSegments(t *testing.T) {
+	timer := NewRepeatCountdownTimer(Config{
+		Segments: []Segment{
+			{Name: "Prep", Phase: RestPhase, Duration: time.Second},
+			{Phase: WorkPhase, Duration: time.Second},
+			{Name: "Sprint", Phase: WorkPhase, Duration: 1500 * time.Millisecond},
+		},
+	})
+
+	startTime := time.Now()
+	timer.Start()
+	assert.InDelta(t, 3*time.Second, time.Since(startTime), float64(PRECISION))
+
+	names := []string{}
+	for len(timer.IntervalName()) > 0 {
+		names = append(names, <-timer.IntervalName())
+	}
+	phases := []Phase{}
+	for len(timer.PhaseStarted()) > 0 {
+		phases = append(phases, <-timer.PhaseStarted())
+	}
+	assert.Equal(t, []string{"Starting", "Prep", "Interval 1/2", "Sprint"}, names)
+	assert.Equal(t, []Phase{RestPhase, WorkPhase, WorkPhase}, phases)
+}
+
+func TestRandomSegmentLength(t *testing.T) {
+	segment := Segment{Phase: RestPhase, Duration: 10 * time.Second, MaxDuration: 30 * time.Second}
+	lengths := func(seed int64) []time.Duration {
+		rng := rand.New(rand.NewSource(seed))
+		lengths := []time.Duration{}
+		for i := 0; i < 50; i++ {
+			lengths = append(lengths, segment.length(rng))
+		}
+		return lengths
+	}
+
+	first := lengths(42)
+	assert.Equal(t, first, lengths(42))
+	assert.NotEqual(t, first, lengths(43))
+	seen := map[time.Duration]bool{}
+	for _, length := range first {
+		assert.GreaterOrEqual(t, length, 10*time.Second)
+		assert.LessOrEqual(t, length, 30*time.Second)
+		assert.Zero(t, length%time.Second)
+		seen[length] = true
+	}
+	assert.Greater(t, len(seen), 1)
+
+	fixed := Segment{Phase: WorkPhase, Duration: 1500 * time.Millisecond}
+	assert.Equal(t, time.Second, fixed.length(rand.New(rand.NewSource(1))))
+}
+
+func TestRound(t *testing.T) {
+	cnf := Config{
+		Intervals:       3,
+		IntervalSeconds: 20,
+		RestSeconds:     10,
+		Repeat:          true,
+	}
+	work := Segment{Phase: WorkPhase, Duration: 20 * time.Second}
+	rest := Segment{Phase: RestPhase, Duration: 10 * time.Second}
+	assert.Equal(t, []Segment{work}, cnf.round(1))
+	assert.Equal(t, []Segment{rest, work}, cnf.round(2))
+
+	cnf.Repeat = false
+	assert.Equal(t, cnf.program(), cnf.round(1))
+	cnf.Segments = []Segment{work, work}
+	cnf.Repeat = true
+	assert.Equal(t, cnf.Segments, cnf.round(5))
+}
+
+func TestRepeatForever(t *testing.T) {
+	timer := NewRepeatCountdownTimer(Config{
+		IntervalSeconds: 1,
+		RestSeconds:     1,
+		Repeat:          true,
+	})
+	go func() {
+		time.Sleep(4500 * time.Millisecond)
+		timer.Cancel()
+	}()
+	timer.Start()
+
+	names := []string{}
+	for len(timer.IntervalName()) > 0 {
+		names = append(names, <-timer.IntervalName())
+	}
+	assert.Equal(t, []string{"Starting", "Interval 1", "Rest", "Interval 2", "Rest", "Interval 3"}, names)
+	assert.True(t, timer.Cancelled())
+	assert.Equal(t, 3, timer.Progress().Round)
+
+	summary := timer.Summary()
+	assert.Equal(t, 2, summary.Rounds)
+	assert.Equal(t, 2, summary.Intervals)
+	assert.InDelta(t, 2500*time.Millisecond, summary.Work, float64(PRECISION))
+	assert.InDelta(t, 2*time.Second, summary.Rest, float64(PRECISION))
+	assert.InDelta(t, 4500*time.Millisecond, summary.Elapsed, float64(PRECISION))
+}
+
+func TestRepeatUntil(t *testing.T) {
+	startTime := time.Now()
+	timer := NewRepeatCountdownTimer(Config{
+		Segments: []Segment{
+			{Name: "Sprint", Phase: WorkPhase, Duration: time.Second},
+			{Phase: RestPhase, Duration: time.Second},
+		},
+		Repeat: true,
+		Until:  startTime.Add(2500 * time.Millisecond),
+	})
+	timer.Start()
+
+	assert.InDelta(t, 2500*time.Millisecond, time.Since(startTime), float64(PRECISION))
+	assert.True(t, timer.ReachedUntil())
+	assert.False(t, timer.Cancelled())
+	assert.Equal(t, 2, timer.Progress().Round)
+	assert.Equal(t, 1, timer.Summary().Rounds)
+	assert.Equal(t, 1, timer.Summary().Intervals)
+}
+
+func TestCancelDoesNotBlock(t *testing.T) {
+	timer := NewRepeatCountdownTimer(Config{Intervals: 1, IntervalSeconds: 1})
+	timer.Start()
+	assert.False(t, timer.Cancelled())
+
+	// there is no interval running to receive the cancel
+	timer.Cancel()
+	assert.True(t, timer.Cancelled())
+	timer.Cancel()
+}
+
+func TestControlsDoNotBlockAfterRun(t *testing.T) {
+	timer := NewRepeatCountdownTimer(Config{Intervals: 1, IntervalSeconds: 1})
+	// there is no run yet
+	timer.Skip()
+	timer.Resume()
+
+	timer.Start()
+	done := make(chan struct{})
+	go func() {
+		timer.Skip()
+		timer.RestartInterval()
+		timer.Pause()
+		timer.Resume()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("controls blocked after the run ended")
+	}
+	assert.False(t, timer.Cancelled())
+}
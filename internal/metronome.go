@@ -0,0 +1,45 @@
+package internal
+
+import (
+	"math"
+	"time"
+)
+
+// Metronome describes the beats played through an interval. The tempo
+// ramps linearly from StartBPM at the start of the interval to EndBPM at its
+// end. A StartBPM of zero disables the metronome.
+type Metronome struct {
+	StartBPM float64
+	EndBPM   float64 // Defaults to StartBPM
+}
+
+// beats returns the time of every beat in an interval of the given length,
+// starting with a beat at the start of the interval.
+func (m Metronome) beats(length time.Duration) []time.Duration {
+	if m.StartBPM <= 0 || length <= 0 {
+		return nil
+	}
+	endBPM := m.EndBPM
+	if endBPM <= 0 {
+		endBPM = m.StartBPM
+	}
+
+	// Beats are where the number of beats elapsed,
+	// n(t) = r0*t + (r1-r0)*t^2/(2*T), is a whole number.
+	r0, r1 := m.StartBPM/60, endBPM/60
+	total := length.Seconds()
+	a := (r1 - r0) / (2 * total)
+	beats := []time.Duration{}
+	for n := 0.0; ; n++ {
+		var t float64
+		if a == 0 {
+			t = n / r0
+		} else {
+			t = (-r0 + math.Sqrt(r0*r0+4*a*n)) / (2 * a)
+		}
+		if math.IsNaN(t) || t >= total {
+			return beats
+		}
+		beats = append(beats, time.Duration(t*float64(time.Second)))
+	}
+}
@@ -0,0 +1,76 @@
+package internal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetronomeBeats(t *testing.T) {
+	constant := Metronome{StartBPM: 120}
+	assert.Equal(t, []time.Duration{0, 500 * time.Millisecond, time.Second, 1500 * time.Millisecond}, constant.beats(2*time.Second))
+
+	off := Metronome{}
+	assert.Empty(t, off.beats(time.Minute))
+
+	// ramping from 60 to 180 BPM over 10s plays (60+180)/2/60*10 = 20 beats,
+	// getting closer together
+	ramp := Metronome{StartBPM: 60, EndBPM: 180}
+	beats := ramp.beats(10 * time.Second)
+	assert.Len(t, beats, 20)
+	assert.Equal(t, time.Duration(0), beats[0])
+	first, last := beats[1]-beats[0], beats[19]-beats[18]
+	assert.InDelta(t, 0.95*float64(time.Second), float64(first), 0.05*float64(time.Second))
+	assert.InDelta(t, 1/3.0*float64(time.Second), float64(last), 0.02*float64(time.Second))
+
+	slowing := Metronome{StartBPM: 180, EndBPM: 60}
+	assert.Len(t, slowing.beats(10*time.Second), 20)
+}
+
+func TestRepeatTimerBeats(t *testing.T) {
+	timer := NewRepeatCountdownTimer(Config{
+		Intervals:       1,
+		IntervalSeconds: 1,
+		WorkMetronome:   Metronome{StartBPM: 240},
+	})
+
+	startTime := time.Now()
+	timer.Start()
+
+	beats := []time.Duration{}
+	for len(timer.Beat()) > 0 {
+		beats = append(beats, (<-timer.Beat()).Sub(startTime))
+	}
+	expected := []time.Duration{0, 250 * time.Millisecond, 500 * time.Millisecond, 750 * time.Millisecond}
+	if assert.Len(t, beats, len(expected)) {
+		for i := range expected {
+			assert.InDelta(t, expected[i], beats[i], float64(PRECISION/3))
+		}
+	}
+}
+
+func TestSegmentMetronome(t *testing.T) {
+	timer := NewRepeatCountdownTimer(Config{
+		WorkMetronome: Metronome{StartBPM: 240},
+		Segments: []Segment{
+			{Phase: WorkPhase, Duration: time.Second, Metronome: &Metronome{StartBPM: 120}},
+			{Phase: WorkPhase, Duration: time.Second, Metronome: &Metronome{}},
+			{Phase: WorkPhase, Duration: time.Second},
+		},
+	})
+
+	startTime := time.Now()
+	timer.Start()
+
+	beats := []time.Duration{}
+	for len(timer.Beat()) > 0 {
+		beats = append(beats, (<-timer.Beat()).Sub(startTime))
+	}
+	expected := []time.Duration{0, 500 * time.Millisecond, 2 * time.Second, 2250 * time.Millisecond, 2500 * time.Millisecond, 2750 * time.Millisecond}
+	if assert.Len(t, beats, len(expected)) {
+		for i := range expected {
+			assert.InDelta(t, expected[i], beats[i], float64(PRECISION/3))
+		}
+	}
+}
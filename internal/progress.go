@@ -0,0 +1,124 @@
+package internal
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Progress is how far a run has got through its program.
+type Progress struct {
+	Round    int           // 1-based number of the current round of a repeating program, 1 otherwise
+	Segment  int           // 1-based number of the current segment in the round, 0 before the first starts
+	Segments int           // Number of segments in the round
+	Part     string        // Part of the session the current segment belongs to, see Segment.Part
+	Done     time.Duration // Length of the round run so far, counting skipped time as run
+	Total    time.Duration // Length of the whole round, counting randomized segments yet to start at their shortest
+	MaxTotal time.Duration // Longest the round can be, more than Total until every randomized segment has started
+}
+
+// Summary is what a run has done, excluding time spent paused.
+type Summary struct {
+	Rounds    int // Rounds run to the end
+	Intervals int // Work intervals run to the end or skipped
+	Work      time.Duration
+	Rest      time.Duration
+	Elapsed   time.Duration
+}
+
+// String describes the summary, such as "3 rounds, 12 intervals, 6m0s work
+// and 2m0s rest in 8m0s".
+func (s Summary) String() string {
+	return fmt.Sprintf("%d rounds, %d intervals, %s work and %s rest in %s",
+		s.Rounds, s.Intervals, s.Work.Round(time.Second), s.Rest.Round(time.Second), s.Elapsed.Round(time.Second))
+}
+
+// progressTracker records the segment a run is on so that its progress can
+// be read while it runs, and totals what the run has done. It is safe for
+// concurrent use.
+type progressTracker struct {
+	mu       sync.Mutex
+	clock    *clock
+	progress Progress
+	length   time.Duration // Length of the current segment
+	started  time.Duration // Time on the clock the current segment started
+	done     Summary
+}
+
+// start resets the tracker for a round of segments between total and
+// maxTotal long. The summary is reset by the first round.
+func (p *progressTracker) start(round, segments int, total, maxTotal time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.progress = Progress{Round: round, Segments: segments, Total: total, MaxTotal: maxTotal}
+	p.length, p.started = 0, 0
+	if round == 1 {
+		p.done = Summary{}
+	}
+}
+
+// next records that the next segment has started, with the given part and
+// length. done is the length of the segments before it in the round. longer
+// and shorter are how much longer the segment is than its shortest and
+// shorter than its longest, which narrow the range of the round's length.
+func (p *progressTracker) next(part string, length, done, longer, shorter time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.progress.Total += longer
+	p.progress.MaxTotal -= shorter
+	p.progress.Segment++
+	p.progress.Part = part
+	p.progress.Done = done
+	p.length, p.started = length, p.clock.elapsed()
+}
+
+// segmentDone records the time spent in the current segment of phase.
+// completed is whether it ran to the end or was skipped rather than the run
+// ending during it.
+func (p *progressTracker) segmentDone(phase Phase, completed bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	spent := p.clock.elapsed() - p.started
+	if phase == WorkPhase {
+		p.done.Work += spent
+		if completed {
+			p.done.Intervals++
+		}
+	} else {
+		p.done.Rest += spent
+	}
+}
+
+// finish records that every segment in the round has run, including any
+// skipped.
+func (p *progressTracker) finish() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.progress.Done = p.progress.Total
+	p.length = 0
+	p.done.Rounds++
+}
+
+// get returns the progress, counting the time the current segment has run
+// for, up to its length.
+func (p *progressTracker) get() Progress {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	progress := p.progress
+	if progress.Segment > 0 {
+		run := p.clock.elapsed() - p.started
+		if run > p.length {
+			run = p.length
+		}
+		progress.Done += run
+	}
+	return progress
+}
+
+func (p *progressTracker) summary() Summary {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	summary := p.done
+	summary.Elapsed = p.clock.elapsed()
+	return summary
+}
@@ -0,0 +1,78 @@
+package internal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgress(t *testing.T) {
+	timer := NewRepeatCountdownTimer(Config{
+		Segments: []Segment{
+			{Phase: WorkPhase, Duration: 2 * time.Second, Part: "Warm up"},
+			{Phase: RestPhase, Duration: time.Second, Part: "Warm up"},
+			{Phase: WorkPhase, Duration: 3 * time.Second, Part: "Main"},
+		},
+	})
+	assert.Equal(t, Progress{}, timer.Progress())
+
+	go func() {
+		time.Sleep(500 * time.Millisecond)
+		progress := timer.Progress()
+		assert.Equal(t, 1, progress.Segment)
+		assert.Equal(t, 3, progress.Segments)
+		assert.Equal(t, "Warm up", progress.Part)
+		assert.Equal(t, 6*time.Second, progress.Total)
+		assert.InDelta(t, 500*time.Millisecond, progress.Done, float64(PRECISION))
+
+		// skipped time counts as run
+		timer.Skip()
+		time.Sleep(100 * time.Millisecond)
+		timer.Skip()
+		time.Sleep(100 * time.Millisecond)
+		progress = timer.Progress()
+		assert.Equal(t, 3, progress.Segment)
+		assert.Equal(t, "Main", progress.Part)
+		assert.InDelta(t, 3*time.Second, progress.Done, float64(PRECISION))
+		timer.Skip()
+	}()
+	timer.Start()
+
+	progress := timer.Progress()
+	assert.Equal(t, 3, progress.Segment)
+	assert.Equal(t, 6*time.Second, progress.Done)
+}
+
+func TestProgressRandomized(t *testing.T) {
+	timer := NewRepeatCountdownTimer(Config{
+		Segments: []Segment{
+			{Phase: WorkPhase, Duration: time.Second},
+			{Phase: RestPhase, Duration: time.Second, MaxDuration: 3 * time.Second},
+		},
+		Seed: 1,
+	})
+
+	go func() {
+		time.Sleep(500 * time.Millisecond)
+		// the random length isn't chosen until its segment starts
+		progress := timer.Progress()
+		assert.Equal(t, 2*time.Second, progress.Total)
+		assert.Equal(t, 4*time.Second, progress.MaxTotal)
+		timer.Skip()
+		time.Sleep(100 * time.Millisecond)
+		progress = timer.Progress()
+		assert.Equal(t, progress.Total, progress.MaxTotal)
+		timer.Skip()
+	}()
+	timer.Start()
+
+	progress := timer.Progress()
+	assert.Equal(t, progress.Total, progress.Done)
+	assert.Equal(t, progress.Total, progress.MaxTotal)
+}
+
+func TestSummaryString(t *testing.T) {
+	summary := Summary{Rounds: 3, Intervals: 12, Work: 6*time.Minute + 200*time.Millisecond, Rest: 2 * time.Minute, Elapsed: 8*time.Minute + 400*time.Millisecond}
+	assert.Equal(t, "3 rounds, 12 intervals, 6m0s work and 2m0s rest in 8m0s", summary.String())
+}
@@ -0,0 +1,113 @@
+package internal
+
+import (
+	"sort"
+	"time"
+)
+
+// scheduledEvent is an event at a point in an interval.
+type scheduledEvent struct {
+	at     time.Duration       // Time into the interval
+	send   func(due time.Time) // Sends the event, due when it is due
+	always bool                // Send even if the interval ends before the event is due
+}
+
+// intervalSchedule sends each of its events with the time it is due, lead
+// ahead of the event so that cues can be scheduled to line up
+// with it. It tracks pauses and restarts so that the times sent stay aligned
+// with the interval.
+type intervalSchedule struct {
+	lead     time.Duration
+	events   []scheduledEvent
+	next     int       // Index of the next event to send
+	started  time.Time // When the interval would have started had it never been paused
+	pausedAt time.Time
+	paused   bool
+	timer    *time.Timer
+}
+
+func newIntervalSchedule(lead time.Duration, events []scheduledEvent) *intervalSchedule {
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].at < events[j].at
+	})
+	return &intervalSchedule{
+		lead:   lead,
+		events: events,
+	}
+}
+
+// start starts, or restarts, the interval now.
+func (s *intervalSchedule) start() {
+	s.started = time.Now()
+	s.next = 0
+	s.paused = false
+	s.schedule()
+}
+
+// schedule sets the timer for the next event.
+func (s *intervalSchedule) schedule() {
+	if s.next >= len(s.events) {
+		return
+	}
+	wait := time.Until(s.started.Add(s.events[s.next].at)) - s.lead
+	if wait < 0 {
+		wait = 0
+	}
+	if s.timer == nil {
+		s.timer = time.NewTimer(wait)
+		return
+	}
+	stopTimer(s.timer)
+	s.timer.Reset(wait)
+}
+
+// C returns the channel that receives when the next event is due to be sent.
+// Returns nil when there are no events left or the schedule is paused, so
+// that it blocks forever in a select.
+func (s *intervalSchedule) C() <-chan time.Time {
+	if s.timer == nil || s.paused || s.next >= len(s.events) {
+		return nil
+	}
+	return s.timer.C
+}
+
+// fire sends the next event and schedules the one after it.
+func (s *intervalSchedule) fire() {
+	event := s.events[s.next]
+	s.next++
+	event.send(s.started.Add(event.at))
+	s.schedule()
+}
+
+func (s *intervalSchedule) pause() {
+	if s.paused || s.timer == nil {
+		return
+	}
+	stopTimer(s.timer)
+	s.pausedAt = time.Now()
+	s.paused = true
+}
+
+func (s *intervalSchedule) resume() {
+	if !s.paused {
+		return
+	}
+	s.started = s.started.Add(time.Since(s.pausedAt))
+	s.paused = false
+	s.schedule()
+}
+
+// stop stops the schedule. Events that must always be sent and are not yet
+// sent are sent with the current time, because the interval ended before
+// they were due.
+func (s *intervalSchedule) stop() {
+	if s.timer != nil {
+		stopTimer(s.timer)
+	}
+	now := time.Now()
+	for ; s.next < len(s.events); s.next++ {
+		if s.events[s.next].always {
+			s.events[s.next].send(now)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// How late a scheduled start may begin when its Grace isn't set, such as
+// when the computer was busy or asleep at the time.
+var DEFAULT_SCHEDULE_GRACE = time.Minute
+
+// Schedule is when a timer is started on the wall clock: once at the next
+// time of day, or at the time of day on each of the given days.
+type Schedule struct {
+	At   TimeOfDay
+	Days []time.Weekday // Days the start recurs on, none to start once
+
+	// Grace is how late a start may still begin. A start missed by more,
+	// such as while the computer was asleep, is skipped rather than run
+	// late. Zero uses DEFAULT_SCHEDULE_GRACE.
+	Grace time.Duration
+}
+
+// Validate returns an error if any of the schedule's days isn't a weekday.
+func (s Schedule) Validate() error {
+	for _, day := range s.Days {
+		if day < time.Sunday || day > time.Saturday {
+			return fmt.Errorf("invalid day %d", day)
+		}
+	}
+	return nil
+}
+
+// Recurring returns whether the schedule starts on more than one day.
+func (s Schedule) Recurring() bool {
+	return len(s.Days) > 0
+}
+
+// Next returns the first start after now, or the zero time if the schedule
+// has no valid days.
+func (s Schedule) Next(now time.Time) time.Time {
+	if !s.Recurring() {
+		return s.At.Next(now)
+	}
+	for day := 0; day <= 7; day++ {
+		date := now.AddDate(0, 0, day)
+		if next := s.At.On(date); s.on(date.Weekday()) && next.After(now) {
+			return next
+		}
+	}
+	return time.Time{}
+}
+
+// on returns whether the schedule starts on day.
+func (s Schedule) on(day time.Weekday) bool {
+	for _, d := range s.Days {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
+
+// String describes the schedule, such as "18:00" or "07:00 on Mon, Tue".
+func (s Schedule) String() string {
+	if !s.Recurring() {
+		return s.At.String()
+	}
+	days := make([]string, 0, len(s.Days))
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if s.on(day) {
+			days = append(days, day.String()[:3])
+		}
+	}
+	return fmt.Sprintf("%s on %s", s.At, strings.Join(days, ", "))
+}
+
+func (s Schedule) grace() time.Duration {
+	if s.Grace <= 0 {
+		return DEFAULT_SCHEDULE_GRACE
+	}
+	return s.Grace
+}
+
+// ScheduleUpdate is the state of the next scheduled start, sent each second
+// while counting down to it and when it starts or is missed.
+type ScheduleUpdate struct {
+	Start         time.Time // When the start is due
+	TimeRemaining string    // Time until the start, such as "05:00" or "1:05:00"
+	Started       bool
+	Missed        bool // The start passed by more than the schedule's grace before it could begin
+}
+
+// Scheduler starts runs at the times in a schedule. Times are measured on
+// the wall clock, so that starts stay on time when the clock is changed
+// and a start that passes while the computer is asleep is noticed when it
+// wakes.
+type Scheduler struct {
+	schedule   Schedule
+	now        func() time.Time
+	updateC    chan ScheduleUpdate
+	cancelC    chan struct{}
+	cancelOnce sync.Once
+}
+
+func NewScheduler(schedule Schedule) *Scheduler {
+	return newScheduler(schedule, time.Now)
+}
+
+func newScheduler(schedule Schedule, now func() time.Time) *Scheduler {
+	return &Scheduler{
+		schedule: schedule,
+		now:      now,
+		updateC:  make(chan ScheduleUpdate, 10),
+		cancelC:  make(chan struct{}),
+	}
+}
+
+// Run counts down to each start in the schedule and calls start when it is
+// due, which blocks until the run it starts ends. A start that is missed by
+// more than the schedule's grace is reported and skipped. Returns once the
+// only start of a single schedule has run or been missed, when the
+// scheduler is cancelled, or at once if the schedule has no valid days.
+func (s *Scheduler) Run(start func()) {
+	next := s.schedule.Next(s.now())
+	sent := ScheduleUpdate{}
+	for !next.IsZero() {
+		// a cancel between back to back starts or misses ends the schedule
+		// before the next
+		select {
+		case <-s.cancelC:
+			return
+		default:
+		}
+
+		// strip any monotonic reading so that time is measured on the wall
+		// clock, which keeps counting while the computer sleeps
+		remaining := next.Sub(s.now().Round(0))
+		if remaining <= 0 {
+			update := ScheduleUpdate{Start: next, TimeRemaining: formatTimeUntil(0)}
+			if -remaining > s.schedule.grace() {
+				update.Missed = true
+				writeScheduleChannel(s.updateC, update)
+			} else {
+				update.Started = true
+				writeScheduleChannel(s.updateC, update)
+				start()
+			}
+			if !s.schedule.Recurring() {
+				return
+			}
+			next = s.schedule.Next(s.now())
+			continue
+		}
+
+		// round up so that the last second reads 00:01, as in a run
+		secs := (remaining + time.Second - 1) / time.Second * time.Second
+		update := ScheduleUpdate{Start: next, TimeRemaining: formatTimeUntil(secs)}
+		if update != sent {
+			writeScheduleChannel(s.updateC, update)
+			sent = update
+		}
+		// wake when the display changes, which is within a second, to check
+		// the wall clock again
+		wait := time.NewTimer(remaining - secs + time.Second)
+		select {
+		case <-s.cancelC:
+			wait.Stop()
+			return
+		case <-wait.C:
+		}
+	}
+}
+
+// Updates returns the channel that receives the state of the next start.
+func (s *Scheduler) Updates() <-chan ScheduleUpdate {
+	return s.updateC
+}
+
+// Cancel stops counting down to the next start. A run that has already
+// started isn't affected.
+func (s *Scheduler) Cancel() {
+	s.cancelOnce.Do(func() {
+		close(s.cancelC)
+	})
+}
+
+// formatTimeUntil formats d as for TimeRemaining, with hours when d is an
+// hour or more.
+func formatTimeUntil(d time.Duration) string {
+	secs := int64(d / time.Second)
+	if secs < 3600 {
+		return formatTimeRemaining(secs/60, secs%60)
+	}
+	return fmt.Sprintf("%d:%02d:%02d", secs/3600, secs/60%60, secs%60)
+}
+
+func writeScheduleChannel(ch chan<- ScheduleUpdate, out ScheduleUpdate) {
+	select {
+	case ch <- out:
+	default:
+	}
+}
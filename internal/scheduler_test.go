@@ -0,0 +1,138 @@
+package internal
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// wallClock returns a wall clock reading from at, which can be moved
+// forward as if the computer had slept by adding to the returned jump.
+func wallClock(at time.Time) (func() time.Time, *atomic.Int64) {
+	started := time.Now()
+	jump := &atomic.Int64{}
+	return func() time.Time {
+		return at.Add(time.Since(started) + time.Duration(jump.Load()))
+	}, jump
+}
+
+func TestScheduleNext(t *testing.T) {
+	saturday := time.Date(2024, time.March, 9, 18, 0, 0, 0, time.UTC)
+	once := Schedule{At: TimeOfDay{Hour: 7}}
+	assert.Equal(t, time.Date(2024, time.March, 10, 7, 0, 0, 0, time.UTC), once.Next(saturday))
+	assert.Equal(t, "07:00", once.String())
+
+	weekdays := Schedule{At: TimeOfDay{Hour: 7}, Days: []time.Weekday{time.Friday, time.Monday, time.Tuesday, time.Wednesday, time.Thursday}}
+	assert.Equal(t, time.Date(2024, time.March, 11, 7, 0, 0, 0, time.UTC), weekdays.Next(saturday))
+	assert.Equal(t, time.Date(2024, time.March, 12, 7, 0, 0, 0, time.UTC), weekdays.Next(time.Date(2024, time.March, 11, 7, 0, 0, 0, time.UTC)))
+	assert.Equal(t, "07:00 on Mon, Tue, Wed, Thu, Fri", weekdays.String())
+
+	saturdays := Schedule{At: TimeOfDay{Hour: 19}, Days: []time.Weekday{time.Saturday}}
+	assert.Equal(t, time.Date(2024, time.March, 9, 19, 0, 0, 0, time.UTC), saturdays.Next(saturday))
+	assert.Equal(t, time.Date(2024, time.March, 16, 19, 0, 0, 0, time.UTC), saturdays.Next(saturday.Add(2*time.Hour)))
+}
+
+func TestScheduledStart(t *testing.T) {
+	now, _ := wallClock(time.Date(2024, time.March, 9, 17, 59, 58, 0, time.UTC))
+	scheduler := newScheduler(Schedule{At: TimeOfDay{Hour: 18}}, now)
+
+	startTime := time.Now()
+	starts := 0
+	scheduler.Run(func() {
+		starts++
+		assert.InDelta(t, 2*time.Second, time.Since(startTime), float64(PRECISION))
+	})
+	assert.Equal(t, 1, starts)
+
+	updates := []string{}
+	for len(scheduler.Updates()) > 0 {
+		update := <-scheduler.Updates()
+		assert.False(t, update.Missed)
+		updates = append(updates, update.TimeRemaining)
+	}
+	assert.Equal(t, []string{"00:02", "00:01", "00:00"}, updates)
+}
+
+func TestScheduledStartMissedAsleep(t *testing.T) {
+	now, jump := wallClock(time.Date(2024, time.March, 9, 6, 59, 58, 0, time.UTC))
+	scheduler := newScheduler(Schedule{At: TimeOfDay{Hour: 7}, Days: []time.Weekday{time.Saturday, time.Sunday}}, now)
+
+	starts := 0
+	go scheduler.Run(func() { starts++ })
+	assert.Equal(t, "00:02", (<-scheduler.Updates()).TimeRemaining)
+	// sleep through the start
+	jump.Store(int64(10 * time.Minute))
+	missed := <-scheduler.Updates()
+	assert.True(t, missed.Missed)
+	assert.Equal(t, time.Date(2024, time.March, 9, 7, 0, 0, 0, time.UTC), missed.Start)
+
+	// the recurring schedule moves on to the next day
+	next := <-scheduler.Updates()
+	assert.False(t, next.Missed)
+	assert.Equal(t, time.Date(2024, time.March, 10, 7, 0, 0, 0, time.UTC), next.Start)
+	scheduler.Cancel()
+	assert.Equal(t, 0, starts)
+}
+
+func TestScheduledStartLate(t *testing.T) {
+	now, jump := wallClock(time.Date(2024, time.March, 9, 17, 59, 58, 0, time.UTC))
+	scheduler := newScheduler(Schedule{At: TimeOfDay{Hour: 18}, Grace: 5 * time.Minute}, now)
+
+	starts := 0
+	go func() {
+		<-scheduler.Updates()
+		jump.Store(int64(2 * time.Minute))
+	}()
+	scheduler.Run(func() { starts++ })
+	assert.Equal(t, 1, starts)
+	update := <-scheduler.Updates()
+	assert.True(t, update.Started)
+	assert.Equal(t, time.Date(2024, time.March, 9, 18, 0, 0, 0, time.UTC), update.Start)
+}
+
+func TestSchedulerInvalidDays(t *testing.T) {
+	schedule := Schedule{At: TimeOfDay{Hour: 7}, Days: []time.Weekday{7}}
+	assert.Error(t, schedule.Validate())
+	assert.NoError(t, Schedule{At: TimeOfDay{Hour: 7}, Days: []time.Weekday{time.Sunday, time.Saturday}}.Validate())
+
+	now, _ := wallClock(time.Date(2024, time.March, 9, 6, 0, 0, 0, time.UTC))
+	scheduler := newScheduler(schedule, now)
+	returned := make(chan struct{})
+	go func() {
+		scheduler.Run(func() { t.Error("started a schedule with no valid days") })
+		close(returned)
+	}()
+	select {
+	case <-returned:
+	case <-time.After(time.Second):
+		scheduler.Cancel()
+		t.Fatal("Run didn't return for a schedule with no valid days")
+	}
+}
+
+func TestSchedulerCancelBetweenMisses(t *testing.T) {
+	// each reading of the clock is a day later, as if the computer slept
+	// through every start, so Run goes from one miss straight to the next
+	at := time.Date(2024, time.March, 9, 6, 0, 0, 0, time.UTC)
+	days := atomic.Int64{}
+	now := func() time.Time {
+		return at.AddDate(0, 0, int(days.Add(1)))
+	}
+	everyDay := []time.Weekday{time.Sunday, time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday}
+	scheduler := newScheduler(Schedule{At: TimeOfDay{Hour: 7}, Days: everyDay}, now)
+
+	returned := make(chan struct{})
+	go func() {
+		scheduler.Run(func() { t.Error("started a missed start") })
+		close(returned)
+	}()
+	assert.True(t, (<-scheduler.Updates()).Missed)
+	scheduler.Cancel()
+	select {
+	case <-returned:
+	case <-time.After(time.Second):
+		t.Fatal("Run didn't return after Cancel")
+	}
+}
@@ -0,0 +1,44 @@
+package internal
+
+import (
+	"fmt"
+	"time"
+)
+
+// TimeOfDay is a time on the wall clock, such as 18:00.
+type TimeOfDay struct {
+	Hour, Minute, Second int
+}
+
+// ParseTimeOfDay parses a 24-hour time of day written as "18:00" or
+// "18:00:30".
+func ParseTimeOfDay(s string) (TimeOfDay, error) {
+	for _, layout := range []string{"15:04", "15:04:05"} {
+		if parsed, err := time.Parse(layout, s); err == nil {
+			return TimeOfDay{Hour: parsed.Hour(), Minute: parsed.Minute(), Second: parsed.Second()}, nil
+		}
+	}
+	return TimeOfDay{}, fmt.Errorf("time %q must be written as 18:00 or 18:00:30", s)
+}
+
+func (t TimeOfDay) String() string {
+	if t.Second != 0 {
+		return fmt.Sprintf("%02d:%02d:%02d", t.Hour, t.Minute, t.Second)
+	}
+	return fmt.Sprintf("%02d:%02d", t.Hour, t.Minute)
+}
+
+// On returns the time of day on the day of date, in date's location.
+func (t TimeOfDay) On(date time.Time) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), t.Hour, t.Minute, t.Second, 0, date.Location())
+}
+
+// Next returns the first time after now at the time of day, today or
+// tomorrow.
+func (t TimeOfDay) Next(now time.Time) time.Time {
+	next := t.On(now)
+	if !next.After(now) {
+		next = t.On(now.AddDate(0, 0, 1))
+	}
+	return next
+}
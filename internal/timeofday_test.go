@@ -0,0 +1,33 @@
+package internal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTimeOfDay(t *testing.T) {
+	tod, err := ParseTimeOfDay("18:00")
+	require.NoError(t, err)
+	assert.Equal(t, TimeOfDay{Hour: 18}, tod)
+	assert.Equal(t, "18:00", tod.String())
+
+	tod, err = ParseTimeOfDay("07:05:30")
+	require.NoError(t, err)
+	assert.Equal(t, TimeOfDay{Hour: 7, Minute: 5, Second: 30}, tod)
+	assert.Equal(t, "07:05:30", tod.String())
+
+	for _, s := range []string{"", "18", "6pm", "24:00", "18:60"} {
+		_, err := ParseTimeOfDay(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestTimeOfDayNext(t *testing.T) {
+	now := time.Date(2024, time.March, 9, 18, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2024, time.March, 9, 19, 30, 0, 0, time.UTC), TimeOfDay{Hour: 19, Minute: 30}.Next(now))
+	assert.Equal(t, time.Date(2024, time.March, 10, 18, 0, 0, 0, time.UTC), TimeOfDay{Hour: 18}.Next(now))
+	assert.Equal(t, time.Date(2024, time.March, 10, 7, 0, 0, 0, time.UTC), TimeOfDay{Hour: 7}.Next(now))
+}
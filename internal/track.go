@@ -0,0 +1,90 @@
+package internal
+
+import (
+	"math/rand"
+	"time"
+)
+
+// Track is a sequence of segments run alongside the program on the same
+// clock, such as an overall time cap. Tracks pause, resume and cancel with
+// the program, but aren't affected by skipping or restarting its intervals.
+type Track struct {
+	Name        string    // Shown while a segment without a name runs
+	Segments    []Segment // Run once, in order
+	EndsProgram bool      // Whether the program ends when the track finishes, as for a time cap
+}
+
+// TrackUpdate is the state of a track running alongside the program, sent
+// when it starts, each second and when it finishes.
+type TrackUpdate struct {
+	Track         int    // Index of the track in Config.Tracks
+	Name          string // Name of the track's current segment
+	TimeRemaining string // Time remaining in the current segment, formatted as for TimeRemaining
+	Finished      bool
+}
+
+// runTrack runs the track at index in Config.Tracks until it finishes or
+// done is closed. Segment ends are measured on the timer's clock so that
+// the track stays in step with the program through pauses.
+func (t *RepeatTimer) runTrack(index int, track Track, rng *rand.Rand, done <-chan struct{}) {
+	var end time.Duration
+	sent := TrackUpdate{}
+	for _, segment := range track.Segments {
+		update := TrackUpdate{Track: index, Name: segment.Name}
+		if update.Name == "" {
+			update.Name = track.Name
+		}
+		end += segment.length(rng)
+		for {
+			remaining := end - t.clock.elapsed()
+			if remaining <= 0 {
+				break
+			}
+			// round up so that the last second reads 00:01, as in the program
+			secs := int64((remaining + time.Second - 1) / time.Second)
+			update.TimeRemaining = formatTimeRemaining(secs/60, secs%60)
+			if update != sent {
+				writeTrackChannel(t.trackC, update)
+				sent = update
+			}
+			wait := time.NewTimer(remaining - time.Duration(secs-1)*time.Second)
+			select {
+			case <-done:
+				wait.Stop()
+				return
+			case <-wait.C:
+			}
+		}
+	}
+	writeTrackChannel(t.trackC, TrackUpdate{Track: index, Name: track.Name, TimeRemaining: formatTimeRemaining(0, 0), Finished: true})
+	if track.EndsProgram {
+		t.endProgram(index, done)
+	}
+}
+
+// endProgram ends the program early because the track at index finished,
+// stopping the current interval. Unlike Cancel, the run counts as
+// completed.
+func (t *RepeatTimer) endProgram(index int, done <-chan struct{}) {
+	if t.ReachedUntil() || !t.endedBy.CompareAndSwap(0, int32(index)+1) {
+		return
+	}
+	t.stopInterval(done)
+}
+
+func writeTrackChannel(ch chan<- TrackUpdate, out TrackUpdate) {
+	select {
+	case ch <- out:
+	default:
+	}
+}
+
+func drainTrackChannel(ch chan TrackUpdate) {
+	for {
+		select {
+		case <-ch:
+		default:
+			return
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package internal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeCap(t *testing.T) {
+	timer := NewRepeatCountdownTimer(Config{
+		Intervals:       3,
+		IntervalSeconds: 2,
+		Tracks: []Track{{
+			Name:        "Time cap",
+			Segments:    []Segment{{Phase: WorkPhase, Duration: 3 * time.Second}},
+			EndsProgram: true,
+		}},
+	})
+
+	startTime := time.Now()
+	timer.Start()
+	assert.InDelta(t, 3*time.Second, time.Since(startTime), float64(PRECISION))
+	assert.Equal(t, 0, timer.EndedBy())
+	assert.False(t, timer.Cancelled())
+
+	updates := []TrackUpdate{}
+	for len(timer.Tracks()) > 0 {
+		updates = append(updates, <-timer.Tracks())
+	}
+	assert.Equal(t, []TrackUpdate{
+		{Name: "Time cap", TimeRemaining: "00:03"},
+		{Name: "Time cap", TimeRemaining: "00:02"},
+		{Name: "Time cap", TimeRemaining: "00:01"},
+		{Name: "Time cap", TimeRemaining: "00:00", Finished: true},
+	}, updates)
+}
+
+func TestTrackOutlastingProgram(t *testing.T) {
+	timer := NewRepeatCountdownTimer(Config{
+		Intervals:       1,
+		IntervalSeconds: 1,
+		Tracks: []Track{{
+			Name: "Cap",
+			Segments: []Segment{
+				{Name: "Part 1", Phase: WorkPhase, Duration: time.Second},
+				{Phase: WorkPhase, Duration: time.Minute},
+			},
+			EndsProgram: true,
+		}},
+	})
+
+	startTime := time.Now()
+	timer.Start()
+	assert.InDelta(t, time.Second, time.Since(startTime), float64(PRECISION))
+	assert.Equal(t, -1, timer.EndedBy())
+
+	names := []string{}
+	for len(timer.Tracks()) > 0 {
+		update := <-timer.Tracks()
+		assert.False(t, update.Finished)
+		names = append(names, update.Name)
+	}
+	assert.Equal(t, "Part 1", names[0])
+	assert.Equal(t, "Cap", names[len(names)-1])
+}
@@ -0,0 +1,156 @@
+package timer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Name of the folder in the app's storage root that imported sounds are
+// copied into.
+var SOUND_LIBRARY_DIR = "sounds"
+
+// ErrSoundExists is returned when a sound can't be added to the sound library
+// because a sound of the same name already exists.
+var ErrSoundExists = errors.New("a sound with that name already exists")
+
+// soundLibrary is a folder of sounds imported by the user. The name of each
+// sound is its file name without the extension.
+type soundLibrary struct {
+	dir string
+}
+
+// sounds returns the names and paths of the audio files in the library,
+// sorted by name. A library folder that doesn't exist yet is empty.
+func (l soundLibrary) sounds() (map[string]string, error) {
+	entries, err := os.ReadDir(l.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]string{}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("reading sound library: %w", err)
+	}
+	sounds := map[string]string{}
+	for _, entry := range entries {
+		if entry.IsDir() || detectAudioFormat(entry.Name(), nil) == formatUnknown {
+			continue
+		}
+		sounds[soundName(entry.Name())] = filepath.Join(l.dir, entry.Name())
+	}
+	return sounds, nil
+}
+
+// names returns the names of the sounds in the library in order.
+func (l soundLibrary) names() ([]string, error) {
+	sounds, err := l.sounds()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(sounds))
+	for name := range sounds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// path returns the path of the sound called name. Returns false if there is
+// no such sound in the library.
+func (l soundLibrary) path(name string) (string, bool) {
+	sounds, err := l.sounds()
+	if err != nil {
+		return "", false
+	}
+	path, exists := sounds[name]
+	return path, exists
+}
+
+// add copies the audio file at src into the library as name, keeping its
+// extension, and returns the path of the copy.
+func (l soundLibrary) add(src, name string) (string, error) {
+	if err := validSoundName(name); err != nil {
+		return "", err
+	}
+	if detectAudioFormat(src, nil) == formatUnknown {
+		return "", fmt.Errorf("%s: %w", src, ErrUnsupportedAudioFormat)
+	}
+	if _, exists := l.path(name); exists {
+		return "", fmt.Errorf("adding %q: %w", name, ErrSoundExists)
+	}
+	if err := os.MkdirAll(l.dir, 0755); err != nil {
+		return "", fmt.Errorf("creating sound library: %w", err)
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return "", err
+	}
+	defer in.Close()
+	dst := filepath.Join(l.dir, name+strings.ToLower(filepath.Ext(src)))
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return "", fmt.Errorf("copying %s: %w", src, err)
+	}
+	if _, err = io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return "", fmt.Errorf("copying %s: %w", src, err)
+	}
+	if err = out.Close(); err != nil {
+		os.Remove(dst)
+		return "", fmt.Errorf("copying %s: %w", src, err)
+	}
+	return dst, nil
+}
+
+// rename renames the sound called from to to and returns its new path.
+func (l soundLibrary) rename(from, to string) (string, error) {
+	if err := validSoundName(to); err != nil {
+		return "", err
+	}
+	path, exists := l.path(from)
+	if !exists {
+		return "", fmt.Errorf("no sound called %q in the library", from)
+	}
+	if _, exists = l.path(to); exists {
+		return "", fmt.Errorf("renaming %q to %q: %w", from, to, ErrSoundExists)
+	}
+	dst := filepath.Join(l.dir, to+filepath.Ext(path))
+	if err := os.Rename(path, dst); err != nil {
+		return "", fmt.Errorf("renaming %q: %w", from, err)
+	}
+	return dst, nil
+}
+
+// remove deletes the sound called name from the library.
+func (l soundLibrary) remove(name string) error {
+	path, exists := l.path(name)
+	if !exists {
+		return fmt.Errorf("no sound called %q in the library", name)
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("deleting %q: %w", name, err)
+	}
+	return nil
+}
+
+// soundName returns the name of the sound stored in the file at path.
+func soundName(path string) string {
+	base := filepath.Base(path)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+// validSoundName returns an error if name can't be used as the file name of
+// a sound in the library.
+func validSoundName(name string) error {
+	switch {
+	case strings.TrimSpace(name) == "":
+		return errors.New("sound name must not be empty")
+	case strings.ContainsAny(name, `/\:*?"<>|`) || strings.HasPrefix(name, "."):
+		return fmt.Errorf("sound name %q contains characters that can't be used in a file name", name)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package timer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSoundLibrary(t *testing.T) {
+	src := t.TempDir()
+	library := soundLibrary{dir: filepath.Join(t.TempDir(), SOUND_LIBRARY_DIR)}
+	for _, name := range []string{"gong.WAV", "bell.mp3", "notes.txt"} {
+		require.NoError(t, os.WriteFile(filepath.Join(src, name), []byte(name), 0644))
+	}
+
+	names, err := library.names()
+	assert.NoError(t, err, "a library that doesn't exist yet is empty")
+	assert.Empty(t, names)
+
+	path, err := library.add(filepath.Join(src, "gong.WAV"), "Gong")
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(library.dir, "Gong.wav"), path)
+	_, err = library.add(filepath.Join(src, "bell.mp3"), "")
+	assert.Error(t, err)
+	_, err = library.add(filepath.Join(src, "bell.mp3"), "Bell")
+	assert.NoError(t, err)
+	_, err = library.add(filepath.Join(src, "bell.mp3"), "Gong")
+	assert.ErrorIs(t, err, ErrSoundExists)
+	_, err = library.add(filepath.Join(src, "notes.txt"), "Notes")
+	assert.ErrorIs(t, err, ErrUnsupportedAudioFormat)
+	_, err = library.add(filepath.Join(src, "bell.mp3"), "../Bell")
+	assert.Error(t, err)
+
+	names, err = library.names()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Bell", "Gong"}, names)
+
+	path, err = library.rename("Gong", "Big Gong")
+	assert.NoError(t, err)
+	contents, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "gong.WAV", string(contents))
+	_, err = library.rename("Big Gong", "Bell")
+	assert.ErrorIs(t, err, ErrSoundExists)
+	_, err = library.rename("Gong", "Small Gong")
+	assert.Error(t, err)
+
+	assert.NoError(t, library.remove("Bell"))
+	assert.Error(t, library.remove("Bell"))
+	names, err = library.names()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Big Gong"}, names)
+}
@@ -0,0 +1,180 @@
+package timer
+
+import (
+	"sort"
+
+	"github.com/faiface/beep"
+)
+
+// PlaybackMode determines what happens when a sound is played while other
+// sounds are still playing.
+type PlaybackMode int
+
+const (
+	// Overlap mixes the sound with any sounds already playing.
+	Overlap PlaybackMode = iota
+	// Queue waits for all playing sounds to finish before starting the
+	// sound. Queued sounds start in order of descending priority.
+	Queue
+	// Interrupt stops playing and queued sounds of the same or lower
+	// priority, then starts the sound immediately.
+	Interrupt
+)
+
+// CuePolicy is the playback policy of a registered sound.
+type CuePolicy struct {
+	Mode     PlaybackMode
+	Priority int
+	NoDuck   bool // Don't duck background music while the sound plays
+}
+
+// voice is a single playback of a sound in a cueMixer.
+type voice struct {
+	streamer beep.Streamer
+	priority int
+	duck     bool // Whether background music is ducked while it plays
+	done     chan<- bool
+}
+
+// cueMixer is a beep.Streamer that mixes any number of voices together and
+// holds back queued voices until all playing voices have finished. It plays
+// silence when there is nothing to play, so it never drains.
+//
+// cueMixer is not safe for concurrent use. Once it is playing through the
+// speaker all calls must be made while holding speaker.Lock.
+type cueMixer struct {
+	playing []*voice
+	queued  []*voice
+	buf     [][2]float64
+}
+
+// add adds a voice to the mixer according to policy.
+func (m *cueMixer) add(v *voice, policy CuePolicy) {
+	switch policy.Mode {
+	case Queue:
+		if len(m.playing) == 0 {
+			m.playing = append(m.playing, v)
+			return
+		}
+		m.queued = append(m.queued, v)
+		// stable so that voices of equal priority are played in order
+		sort.SliceStable(m.queued, func(i, j int) bool {
+			return m.queued[i].priority > m.queued[j].priority
+		})
+	case Interrupt:
+		m.playing = m.stopAtOrBelow(m.playing, v.priority)
+		m.queued = m.stopAtOrBelow(m.queued, v.priority)
+		m.playing = append(m.playing, v)
+	default:
+		m.playing = append(m.playing, v)
+	}
+}
+
+// stopAtOrBelow finishes every voice in voices with priority at or below
+// priority and returns the remaining voices.
+func (m *cueMixer) stopAtOrBelow(voices []*voice, priority int) []*voice {
+	remaining := voices[:0]
+	for _, v := range voices {
+		if v.priority <= priority {
+			finishVoice(v)
+			continue
+		}
+		remaining = append(remaining, v)
+	}
+	return remaining
+}
+
+// clear stops all playing and queued voices.
+func (m *cueMixer) clear() {
+	for _, v := range m.playing {
+		finishVoice(v)
+	}
+	for _, v := range m.queued {
+		finishVoice(v)
+	}
+	m.playing = nil
+	m.queued = nil
+}
+
+// active returns the number of playing and queued voices.
+func (m *cueMixer) active() int {
+	return len(m.playing) + len(m.queued)
+}
+
+// ducking returns the number of playing voices that duck background music.
+func (m *cueMixer) ducking() int {
+	n := 0
+	for _, v := range m.playing {
+		if v.duck {
+			n++
+		}
+	}
+	return n
+}
+
+func (m *cueMixer) Stream(samples [][2]float64) (n int, ok bool) {
+	for i := range samples {
+		samples[i] = [2]float64{}
+	}
+	if len(m.buf) < len(samples) {
+		m.buf = make([][2]float64, len(samples))
+	}
+
+	// A queued voice starting part way through samples is mixed in from
+	// the point at which the previous voices finished.
+	offset := 0
+	for offset < len(samples) {
+		if len(m.playing) == 0 {
+			if len(m.queued) == 0 {
+				break
+			}
+			m.playing = append(m.playing, m.queued[0])
+			m.queued = m.queued[1:]
+		}
+
+		longest := 0
+		remaining := m.playing[:0]
+		for _, v := range m.playing {
+			sn, sok := v.streamer.Stream(m.buf[:len(samples)-offset])
+			for i := 0; i < sn; i++ {
+				samples[offset+i][0] += m.buf[i][0]
+				samples[offset+i][1] += m.buf[i][1]
+			}
+			if sn > longest {
+				longest = sn
+			}
+			if !sok || sn < len(samples)-offset {
+				finishVoice(v)
+				continue
+			}
+			remaining = append(remaining, v)
+		}
+		m.playing = remaining
+
+		if len(m.playing) > 0 {
+			break
+		}
+		offset += longest
+		if longest == 0 && len(m.queued) == 0 {
+			break
+		}
+	}
+	return len(samples), true
+}
+
+func (m *cueMixer) Err() error {
+	return nil
+}
+
+// finishVoice signals on the voice's done channel, if it has one, without
+// blocking the audio thread.
+func finishVoice(v *voice) {
+	if v.done == nil {
+		return
+	}
+	done := v.done
+	v.done = nil
+	go func() {
+		done <- true
+	}()
+}
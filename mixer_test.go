@@ -0,0 +1,77 @@
+package timer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/faiface/beep"
+	"github.com/stretchr/testify/assert"
+)
+
+// constant returns a streamer of n samples with value v.
+func constant(v float64, n int) beep.Streamer {
+	return beep.Take(n, beep.StreamerFunc(func(samples [][2]float64) (int, bool) {
+		for i := range samples {
+			samples[i] = [2]float64{v, v}
+		}
+		return len(samples), true
+	}))
+}
+
+func streamLeft(m *cueMixer, n int) []float64 {
+	samples := make([][2]float64, n)
+	m.Stream(samples)
+	left := make([]float64, n)
+	for i := range samples {
+		left[i] = samples[i][0]
+	}
+	return left
+}
+
+func TestCueMixerOverlap(t *testing.T) {
+	m := &cueMixer{}
+	m.add(&voice{streamer: constant(1, 2)}, CuePolicy{Mode: Overlap})
+	m.add(&voice{streamer: constant(2, 4)}, CuePolicy{Mode: Overlap})
+
+	assert.Equal(t, []float64{3, 3, 2, 2, 0, 0}, streamLeft(m, 6))
+	assert.Zero(t, m.active())
+}
+
+func TestCueMixerQueue(t *testing.T) {
+	m := &cueMixer{}
+	m.add(&voice{streamer: constant(1, 2)}, CuePolicy{Mode: Queue})
+	m.add(&voice{streamer: constant(2, 1), priority: 0}, CuePolicy{Mode: Queue})
+	m.add(&voice{streamer: constant(3, 1), priority: 5}, CuePolicy{Mode: Queue, Priority: 5})
+	assert.Equal(t, 3, m.active())
+
+	// higher priority queued voice plays first
+	assert.Equal(t, []float64{1, 1, 3, 2, 0}, streamLeft(m, 5))
+	assert.Zero(t, m.active())
+}
+
+func TestCueMixerInterrupt(t *testing.T) {
+	m := &cueMixer{}
+	done := make(chan bool, 1)
+	m.add(&voice{streamer: constant(1, 10), done: done}, CuePolicy{Mode: Overlap})
+	m.add(&voice{streamer: constant(2, 10), priority: 9}, CuePolicy{Mode: Overlap, Priority: 9})
+	assert.Equal(t, []float64{3, 3}, streamLeft(m, 2))
+
+	m.add(&voice{streamer: constant(4, 2), priority: 1}, CuePolicy{Mode: Interrupt, Priority: 1})
+	assert.Equal(t, []float64{6, 6, 2}, streamLeft(m, 3))
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("interrupted voice did not signal done")
+	}
+}
+
+func TestCueMixerClear(t *testing.T) {
+	m := &cueMixer{}
+	m.add(&voice{streamer: constant(1, 10)}, CuePolicy{Mode: Overlap})
+	m.add(&voice{streamer: constant(1, 10)}, CuePolicy{Mode: Queue})
+	m.clear()
+
+	assert.Zero(t, m.active())
+	assert.Equal(t, []float64{0, 0}, streamLeft(m, 2))
+}
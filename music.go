@@ -0,0 +1,207 @@
+package timer
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/faiface/beep"
+)
+
+// Gain of background music while cues are playing, relative to the music
+// volume, if none is configured.
+var DEFAULT_DUCK_VOLUME = 0.25
+
+// How long background music takes to duck or recover.
+var DUCK_RAMP = 80 * time.Millisecond
+
+// MusicConfig configures the background music played while the timer runs.
+type MusicConfig struct {
+	WorkDir    string  // Folder of music played during work intervals
+	RestDir    string  // Folder of music played during rest, no music is played during rest if empty
+	Shuffle    bool    // Whether to play each folder in a random order
+	Volume     float64 // Gain of the music between 0 and 1, defaults to 1
+	DuckVolume float64 // Gain of the music while cues play, relative to Volume, defaults to DEFAULT_DUCK_VOLUME
+}
+
+// playlist is a beep.Streamer that plays every audio file in a folder in
+// turn, looping back to the start when it reaches the end. Files are
+// decoded as they are played rather than held in memory.
+type playlist struct {
+	sampleRate beep.SampleRate
+	files      []string
+	shuffle    bool
+	rand       *rand.Rand
+	next       int
+	current    beep.StreamSeekCloser
+	resampled  beep.Streamer
+	err        error
+}
+
+// newPlaylist returns a playlist of the audio files in dir resampled to the
+// sample rate sr. Returns an error if dir can't be read or contains no audio
+// files.
+func newPlaylist(sr beep.SampleRate, dir string, shuffle bool) (*playlist, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading music folder: %w", err)
+	}
+	files := []string{}
+	for _, entry := range entries {
+		if !entry.IsDir() && detectAudioFormat(entry.Name(), nil) != formatUnknown {
+			files = append(files, filepath.Join(dir, entry.Name()))
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("music folder %s contains no audio files", dir)
+	}
+	sort.Strings(files)
+
+	p := &playlist{
+		sampleRate: sr,
+		files:      files,
+		shuffle:    shuffle,
+		rand:       rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	if shuffle {
+		p.rand.Shuffle(len(p.files), func(i, j int) { p.files[i], p.files[j] = p.files[j], p.files[i] })
+	}
+	return p, nil
+}
+
+func (p *playlist) Stream(samples [][2]float64) (n int, ok bool) {
+	for n < len(samples) {
+		if p.current == nil && !p.openNext() {
+			return n, n > 0
+		}
+		sn, sok := p.resampled.Stream(samples[n:])
+		n += sn
+		if !sok || sn == 0 {
+			if err := p.current.Err(); err != nil {
+				log.Printf("error playing music: %v\n", err)
+			}
+			p.current.Close()
+			p.current = nil
+		}
+	}
+	return n, true
+}
+
+// openNext opens the next file in the playlist that can be decoded,
+// reshuffling when the end of the playlist is reached. Returns false if no
+// file in the playlist can be decoded.
+func (p *playlist) openNext() bool {
+	for tries := 0; tries < len(p.files); tries++ {
+		if p.next == len(p.files) {
+			p.next = 0
+			if p.shuffle {
+				p.rand.Shuffle(len(p.files), func(i, j int) { p.files[i], p.files[j] = p.files[j], p.files[i] })
+			}
+		}
+		path := p.files[p.next]
+		p.next++
+
+		streamer, format, err := decodeFile(path)
+		if err != nil {
+			log.Printf("error playing music: %v\n", err)
+			continue
+		}
+		p.current = streamer
+		p.resampled = beep.Resample(3, format.SampleRate, p.sampleRate, streamer)
+		return true
+	}
+	p.err = errors.New("no music in the playlist could be played")
+	return false
+}
+
+func (p *playlist) Err() error {
+	return p.err
+}
+
+// Close closes the file currently being played.
+func (p *playlist) Close() error {
+	if p.current == nil {
+		return nil
+	}
+	err := p.current.Close()
+	p.current = nil
+	return err
+}
+
+// ducker is a beep.Streamer that plays background music, lowering its
+// volume while cues are playing in cues. It plays silence when there is no
+// music or the music is paused, so it never drains.
+//
+// ducker is not safe for concurrent use. Once it is playing through the
+// speaker all calls must be made while holding speaker.Lock.
+type ducker struct {
+	cues       *cueMixer
+	music      beep.Streamer
+	paused     bool
+	volume     float64
+	duckVolume float64 // Relative to volume
+	gain       float64 // Current gain, ramped towards its target
+	step       float64 // Maximum change in gain per sample
+}
+
+func newDucker(sr beep.SampleRate, cues *cueMixer) *ducker {
+	return &ducker{
+		cues:       cues,
+		volume:     1,
+		duckVolume: DEFAULT_DUCK_VOLUME,
+		gain:       1,
+		step:       1 / float64(sr.N(DUCK_RAMP)),
+	}
+}
+
+func (d *ducker) Stream(samples [][2]float64) (n int, ok bool) {
+	for i := range samples {
+		samples[i] = [2]float64{}
+	}
+	if d.music == nil || d.paused {
+		return len(samples), true
+	}
+
+	target := d.volume
+	if d.cues.ducking() > 0 {
+		target = d.volume * d.duckVolume
+	}
+	sn, sok := d.music.Stream(samples)
+	for i := range samples[:sn] {
+		switch {
+		case d.gain < target:
+			d.gain = minFloat(d.gain+d.step, target)
+		case d.gain > target:
+			d.gain = maxFloat(d.gain-d.step, target)
+		}
+		samples[i][0] *= d.gain
+		samples[i][1] *= d.gain
+	}
+	if !sok {
+		d.music = nil
+	}
+	return len(samples), true
+}
+
+func (d *ducker) Err() error {
+	return nil
+}
+
+func minFloat(a, b float64) float64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxFloat(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,70 @@
+package timer
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/faiface/beep"
+	"github.com/faiface/beep/wav"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeWav writes n samples of value v to a WAV file at path.
+func writeWav(t *testing.T, path string, v float64, n int) {
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+	format := beep.Format{SampleRate: 1000, NumChannels: 2, Precision: 2}
+	require.NoError(t, wav.Encode(f, constant(v, n), format))
+}
+
+func TestPlaylist(t *testing.T) {
+	dir := t.TempDir()
+	writeWav(t, filepath.Join(dir, "1.wav"), 0.5, 3)
+	writeWav(t, filepath.Join(dir, "2.wav"), -0.5, 2)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not music"), 0o644))
+
+	p, err := newPlaylist(1000, dir, false)
+	require.NoError(t, err)
+	defer p.Close()
+	assert.Len(t, p.files, 2)
+
+	samples := make([][2]float64, 7)
+	n, ok := p.Stream(samples)
+	assert.True(t, ok)
+	assert.Equal(t, 7, n)
+	// files are told apart by sign, looping back to the start
+	expected := []float64{1, 1, 1, -1, -1, 1, 1}
+	for i := range expected {
+		assert.Equal(t, expected[i], math.Copysign(1, samples[i][0]), "sample %d", i)
+	}
+}
+
+func TestPlaylistEmptyFolder(t *testing.T) {
+	_, err := newPlaylist(1000, t.TempDir(), false)
+	assert.Error(t, err)
+}
+
+func TestDucker(t *testing.T) {
+	cues := &cueMixer{}
+	d := newDucker(1000, cues)
+	d.duckVolume = 0.5
+	d.step = 0.25
+	d.music = constant(1, 100)
+
+	samples := make([][2]float64, 2)
+	d.Stream(samples)
+	assert.Equal(t, 1.0, samples[1][0])
+
+	cues.add(&voice{streamer: constant(0, 100), duck: true}, CuePolicy{})
+	samples = make([][2]float64, 3)
+	d.Stream(samples)
+	assert.Equal(t, []float64{0.75, 0.5, 0.5}, []float64{samples[0][0], samples[1][0], samples[2][0]})
+
+	d.paused = true
+	d.Stream(samples)
+	assert.Zero(t, samples[0][0])
+}
@@ -0,0 +1,189 @@
+package timer
+
+import (
+	"log"
+	"time"
+
+	"github.com/faiface/beep"
+	"github.com/faiface/beep/effects"
+	"github.com/faiface/beep/speaker"
+)
+
+// AudioBackend selects how an application plays sounds.
+type AudioBackend int
+
+const (
+	// SpeakerBackend plays sounds through the system's default audio
+	// device, falling back to SilentBackend if the device is unavailable.
+	SpeakerBackend AudioBackend = iota
+	// SilentBackend discards all sounds. Useful on machines without an
+	// audio device.
+	SilentBackend
+)
+
+// player is an audio backend that plays sounds decoded into memory.
+type player interface {
+	// PlaySound plays stream according to its cue policy. It takes an
+	// optional done channel on which a signal will be sent when the audio
+	// finishes playing or is stopped.
+	PlaySound(stream audioStream, done chan<- bool)
+	// PlaySoundAt schedules stream to be heard at the time at, taking the
+	// player's output latency into account. If at is too soon the sound is
+	// played immediately.
+	PlaySoundAt(stream audioStream, at time.Time, done chan<- bool)
+	// Latency returns the time taken for a sound to be heard after it is
+	// played.
+	Latency() time.Duration
+	// StopAll stops all playing and queued sounds.
+	StopAll()
+	// SetVolume sets the master volume to the linear gain v, where 0 is
+	// silent and 1 is unchanged.
+	SetVolume(v float64)
+	// SetMusic replaces the background music with music, or stops the
+	// background music if music is nil. Background music is ducked while
+	// sounds are playing.
+	SetMusic(music beep.Streamer)
+	// PauseMusic pauses or resumes the background music.
+	PauseMusic(paused bool)
+	// SetMusicVolume sets the gain of the background music, and the gain
+	// relative to it while sounds are playing.
+	SetMusicVolume(volume, duckVolume float64)
+}
+
+// newPlayer returns a player for backend. latency is the output latency of
+// the audio device on top of the speaker buffer. If the speaker can't be
+// initialized the error is logged and a silent player is returned instead,
+// so the application can still run.
+func newPlayer(backend AudioBackend, sr beep.SampleRate, buffRatio int, latency time.Duration) player {
+	if backend == SilentBackend {
+		return silentPlayer{}
+	}
+	p, err := NewSpeakerPlayer(sr, buffRatio, latency)
+	if err != nil {
+		log.Printf("error initializing speaker, audio disabled: %v\n", err)
+		return silentPlayer{}
+	}
+	return p
+}
+
+// speakerPlayer plays sounds through the system's default audio device.
+type speakerPlayer struct {
+	sampleRate beep.SampleRate
+	latency    time.Duration
+	mixer      *cueMixer
+	music      *ducker
+	volume     *effects.Volume // Master volume applied to the cues and music
+}
+
+// NewSpeakerPlayer initializes the speaker with a sample rate of sr,
+// and a buffer size of sr/buffRatio, and starts playing the player's mixer
+// through it. latency is the output latency of the audio device on top of
+// the speaker buffer. Returns an error if there is an error initializing the
+// speaker.
+func NewSpeakerPlayer(sr beep.SampleRate, buffRatio int, latency time.Duration) (*speakerPlayer, error) {
+	bufferSize := sr.N(time.Second) / buffRatio
+	err := speaker.Init(sr, bufferSize)
+	if err != nil {
+		return nil, err
+	}
+	p := &speakerPlayer{
+		sampleRate: sr,
+		latency:    sr.D(bufferSize) + latency,
+		mixer:      &cueMixer{},
+	}
+	p.music = newDucker(sr, p.mixer)
+	p.volume = newVolume(beep.Mix(p.mixer, p.music), 1)
+	speaker.Play(p.volume)
+	return p, nil
+}
+
+func (p *speakerPlayer) PlaySound(stream audioStream, done chan<- bool) {
+	p.play(stream.streamer(), stream, done)
+}
+
+func (p *speakerPlayer) PlaySoundAt(stream audioStream, at time.Time, done chan<- bool) {
+	delay := time.Until(at) - p.latency
+	if delay <= 0 {
+		p.PlaySound(stream, done)
+		return
+	}
+	// Delaying with silence in the mixer is sample accurate, unlike a timer.
+	p.play(beep.Seq(beep.Silence(p.sampleRate.N(delay)), stream.streamer()), stream, done)
+}
+
+func (p *speakerPlayer) Latency() time.Duration {
+	return p.latency
+}
+
+func (p *speakerPlayer) SetMusic(music beep.Streamer) {
+	speaker.Lock()
+	p.music.music = music
+	p.music.paused = false
+	speaker.Unlock()
+}
+
+func (p *speakerPlayer) PauseMusic(paused bool) {
+	speaker.Lock()
+	p.music.paused = paused
+	speaker.Unlock()
+}
+
+func (p *speakerPlayer) SetMusicVolume(volume, duckVolume float64) {
+	speaker.Lock()
+	p.music.volume = volume
+	p.music.duckVolume = duckVolume
+	speaker.Unlock()
+}
+
+func (p *speakerPlayer) play(streamer beep.Streamer, stream audioStream, done chan<- bool) {
+	speaker.Lock()
+	p.mixer.add(&voice{
+		streamer: streamer,
+		priority: stream.policy.Priority,
+		duck:     !stream.policy.NoDuck,
+		done:     done,
+	}, stream.policy)
+	speaker.Unlock()
+}
+
+func (p *speakerPlayer) StopAll() {
+	speaker.Lock()
+	p.mixer.clear()
+	speaker.Unlock()
+}
+
+func (p *speakerPlayer) SetVolume(v float64) {
+	speaker.Lock()
+	setVolume(p.volume, v)
+	speaker.Unlock()
+}
+
+// silentPlayer is a player that discards all sounds. Sounds finish as soon
+// as they are played.
+type silentPlayer struct{}
+
+func (p silentPlayer) PlaySound(stream audioStream, done chan<- bool) {
+	if done != nil {
+		go func() {
+			done <- true
+		}()
+	}
+}
+
+func (p silentPlayer) PlaySoundAt(stream audioStream, at time.Time, done chan<- bool) {
+	p.PlaySound(stream, done)
+}
+
+func (p silentPlayer) Latency() time.Duration {
+	return 0
+}
+
+func (p silentPlayer) StopAll() {}
+
+func (p silentPlayer) SetVolume(v float64) {}
+
+func (p silentPlayer) SetMusic(music beep.Streamer) {}
+
+func (p silentPlayer) PauseMusic(paused bool) {}
+
+func (p silentPlayer) SetMusicVolume(volume, duckVolume float64) {}
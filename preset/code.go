@@ -0,0 +1,72 @@
+package preset
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"strings"
+)
+
+// CODE_PREFIX starts every preset code and identifies the version of the
+// code format.
+const CODE_PREFIX = "tmr1-"
+
+// Largest preset document a code may decompress to.
+const MAX_CODE_DOCUMENT = 1 << 20
+
+// ErrInvalidCode is returned when a preset code is malformed or damaged.
+var ErrInvalidCode = errors.New("invalid preset code")
+
+// Encode returns a short URL-safe code for sharing p. The code is the
+// compressed JSON document with a checksum, encoded as base64. Music folders
+// are local to each computer and are left out.
+func Encode(p *Preset) (string, error) {
+	if err := p.Validate(); err != nil {
+		return "", err
+	}
+	shared := *p
+	shared.Music.WorkDir, shared.Music.RestDir = "", ""
+	doc, err := json.Marshal(&shared)
+	if err != nil {
+		return "", fmt.Errorf("encoding preset: %w", err)
+	}
+	buf := &bytes.Buffer{}
+	w, err := flate.NewWriter(buf, flate.BestCompression)
+	if err != nil {
+		return "", fmt.Errorf("encoding preset: %w", err)
+	}
+	w.Write(doc)
+	if err = w.Close(); err != nil {
+		return "", fmt.Errorf("encoding preset: %w", err)
+	}
+	buf.Write(binary.BigEndian.AppendUint32(nil, crc32.ChecksumIEEE(doc)))
+	return CODE_PREFIX + base64.RawURLEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// Decode returns the preset shared as code and validates it. Whitespace in
+// the code, such as line breaks added when it was pasted, is ignored.
+func Decode(code string) (*Preset, error) {
+	code = strings.Join(strings.Fields(code), "")
+	if !strings.HasPrefix(code, CODE_PREFIX) {
+		if i := strings.Index(code, "-"); i > 0 && strings.HasPrefix(code, CODE_PREFIX[:3]) {
+			return nil, fmt.Errorf("%w: version %q is not supported", ErrInvalidCode, code[3:i])
+		}
+		return nil, fmt.Errorf("%w: must start with %q", ErrInvalidCode, CODE_PREFIX)
+	}
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(code, CODE_PREFIX))
+	if err != nil || len(data) < 4 {
+		return nil, fmt.Errorf("%w: the code is incomplete or contains invalid characters", ErrInvalidCode)
+	}
+	checksum := binary.BigEndian.Uint32(data[len(data)-4:])
+	doc, err := io.ReadAll(io.LimitReader(flate.NewReader(bytes.NewReader(data[:len(data)-4])), MAX_CODE_DOCUMENT+1))
+	if err != nil || len(doc) > MAX_CODE_DOCUMENT || crc32.ChecksumIEEE(doc) != checksum {
+		return nil, fmt.Errorf("%w: checksum doesn't match, the code may have been mistyped", ErrInvalidCode)
+	}
+	return Load(bytes.NewReader(doc), JSON)
+}
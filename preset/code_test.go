@@ -0,0 +1,50 @@
+package preset
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCode(t *testing.T) {
+	code, err := Encode(sprints())
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(code, CODE_PREFIX))
+	assert.NotContains(t, code, "/")
+	assert.NotContains(t, code, "+")
+
+	p, err := Decode(code[:20] + "\n  " + code[20:])
+	require.NoError(t, err)
+	expected := sprints()
+	expected.Music.WorkDir = ""
+	assert.Equal(t, expected, p)
+
+	_, err = Encode(New("Invalid"))
+	assert.Error(t, err)
+}
+
+func TestDecodeInvalidCode(t *testing.T) {
+	code, err := Encode(sprints())
+	require.NoError(t, err)
+	mistyped := []byte(code)
+	i := len(CODE_PREFIX) + 10
+	if mistyped[i] == 'A' {
+		mistyped[i] = 'B'
+	} else {
+		mistyped[i] = 'A'
+	}
+	for _, code := range []string{
+		"",
+		"hello",
+		"tmr9-" + code[len(CODE_PREFIX):],
+		code[:len(code)-6],
+		string(mistyped),
+		code + "!",
+	} {
+		_, err := Decode(code)
+		assert.True(t, errors.Is(err, ErrInvalidCode), "%q: got %v", code, err)
+	}
+}
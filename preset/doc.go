@@ -0,0 +1,89 @@
+// Package preset reads and writes timer presets. A preset is a program of
+// timed segments together with the sounds, cues and music used while it
+// runs, stored as a JSON or YAML document.
+//
+// A preset describes its program either as a number of work intervals
+// separated by rests:
+//
+//	{
+//		"version": 2,
+//		"name": "Tabata",
+//		"program": {
+//			"intervals": 8,
+//			"work": "20s",
+//			"rest": "10s",
+//			"restBeforeStart": true
+//		},
+//		"sounds": {
+//			"intervalEnd": "Ding",
+//			"finish": "Triple Beep"
+//		},
+//		"cues": {
+//			"countdownFrom": 3,
+//			"workMetronome": {"startBPM": 120, "endBPM": 160}
+//		}
+//	}
+//
+// or as a list of segments, where a segment with its own segments is a group
+// run repeat times:
+//
+//	version: 2
+//	name: Sprints
+//	program:
+//	  segments:
+//	    - {name: Warm up, phase: rest, duration: 5m}
+//	    - repeat: 3
+//	      segments:
+//	        - {name: Sprint, phase: work, duration: 30s}
+//	        - {phase: rest, duration: 1m30s}
+//	music:
+//	  workDir: /home/me/Music/Fast
+//	  shuffle: true
+//
+// A program may also be generated by a ladder, whose work intervals change in
+// length by a step each round and, for a pyramid, climb back down:
+//
+//	"program": {
+//		"ladder": {"rounds": 3, "work": "30s", "step": "10s", "pyramid": true, "restRatio": 0.5}
+//	}
+//
+// A program with a cap, such as "cap": "15m", ends when the cap is reached
+// even if it has steps left.
+//
+// A Plan generates a program of segments that fits a time budget exactly from
+// a work to rest ratio and a number of stations.
+//
+// A segment with a maxDuration runs for a random length between its duration
+// and maxDuration, and a group with shuffle runs its segments in a random
+// order, optionally picking only pick of them each time it repeats. The
+// random choices are made from a seed so that a run can be repeated, see
+// Program.Steps.
+//
+// Durations are written as Go durations, such as "1m30s", or as a number of
+// seconds. Every field other than version and program is optional.
+//
+// Programs may also be written in a compact interval notation, read by
+// ParseProgram and written by Program.String:
+//
+//	prep 10s; 3x(8x(20s work "Sprint", 10s rest), 2m rest); cooldown 5m
+//
+// A step with a metronome, such as "metronome": {"startBPM": 20, "endBPM":
+// 28}, clicks at that tempo in place of the metronome cue for its phase.
+//
+// A preset can be shared as a short URL-safe code, written by Encode and read
+// by Decode, which can be copied as text or shown as a QR code.
+//
+// All documents carry the version of the schema they were written with.
+// Documents written with an older version are upgraded when loaded by the
+// chain of migrations in PresetSchema, and LoadReport and MigrateFile report
+// the migrations applied. Load rejects documents written by a newer version
+// of the schema.
+//
+// A History records the runs of the timer, each with the preset it ran, in
+// a history document that is versioned and upgraded in the same way by the
+// chain in HistorySchema. As every run embeds a preset, each migration of
+// PresetSchema is also a migration of HistorySchema.
+//
+// Validation errors name the offending field by its path in the document,
+// such as "program.segments[1].segments[0].duration".
+package preset
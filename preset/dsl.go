@@ -0,0 +1,561 @@
+package preset
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MAX_REPEAT is the largest repeat count accepted by ParseProgram.
+const MAX_REPEAT = 1000
+
+// Named rest segments that may be written as a keyword, such as "prep 10s".
+var restKeywords = map[string]string{
+	"prep":     "Prep",
+	"warmup":   "Warm up",
+	"cooldown": "Cool down",
+}
+
+// SyntaxError is an error in a program written in the interval notation.
+type SyntaxError struct {
+	Line    int // 1-based line of the offending character
+	Column  int // 1-based column of the offending character, in runes
+	Message string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Message)
+}
+
+// ParseProgram parses a program written in the interval notation, such as
+//
+//	prep 10s; 3x(8x(20s work "Sprint", 10s rest), 2m rest); cooldown 5m
+//
+// A program is a list of items separated by ";" or ",". Each item is either
+// a step or a group of items in parentheses. A step is a duration and a
+// phase, "work" or "rest", in either order and optionally followed by a
+// quoted name. The keywords "prep", "warmup" and "cooldown" may be used in
+// place of the phase for named rests. A count such as "3x" before an item
+// repeats it. Durations are Go durations or a number of seconds, and a range
+// such as "10s..30s" randomizes the length of the step. "shuffle" before a
+// group runs its items in a random order, and "shuffle 2" picks two of them.
+// A step may end with a metronome tempo in beats per minute, such as "@24",
+// or a ramp such as "@20..28". "@0" silences the metronome for the step.
+// A top level item "cap 15m" ends the program after 15 minutes.
+//
+// Errors are returned as a *SyntaxError giving the position of the problem.
+func ParseProgram(s string) (Program, error) {
+	p := &parser{src: s}
+	p.next()
+	segments, err := p.items(tokEOF)
+	if err != nil {
+		return Program{}, err
+	}
+	if len(segments) == 0 {
+		return Program{}, p.errorf(p.tok.pos, "expected a step or group, found %s", p.describe())
+	}
+	return Program{Segments: segments, Cap: p.cap}, nil
+}
+
+// String returns the program in the interval notation read by ParseProgram.
+// A program of segments is written so that parsing it returns an equal
+// program. Programs of intervals and ladders are written as the equivalent
+// segments.
+func (p Program) String() string {
+	s := p.steps()
+	if p.Cap != 0 && s != "" {
+		return "cap " + formatDuration(p.Cap) + "; " + s
+	}
+	return s
+}
+
+// steps returns the program's steps in the interval notation.
+func (p Program) steps() string {
+	if p.Ladder != nil {
+		return formatItems(p.Ladder.Segments(), "; ")
+	}
+	if len(p.Segments) > 0 {
+		return formatItems(p.Segments, "; ")
+	}
+	if p.Intervals <= 0 {
+		return ""
+	}
+	work := formatDuration(p.Work) + " work"
+	if p.Rest == 0 {
+		if p.Intervals == 1 {
+			return work
+		}
+		return fmt.Sprintf("%dx %s", p.Intervals, work)
+	}
+	rest := formatDuration(p.Rest) + " rest"
+	items := []string{}
+	if p.RestBeforeStart {
+		items = append(items, rest)
+	}
+	items = append(items, work)
+	if p.Intervals > 1 {
+		items = append(items, fmt.Sprintf("%dx(%s, %s)", p.Intervals-1, rest, work))
+	}
+	return strings.Join(items, "; ")
+}
+
+func formatItems(segments []Segment, sep string) string {
+	items := make([]string, len(segments))
+	for i, s := range segments {
+		items[i] = formatItem(s)
+	}
+	return strings.Join(items, sep)
+}
+
+func formatItem(s Segment) string {
+	if len(s.Segments) > 0 {
+		group := "(" + formatItems(s.Segments, ", ") + ")"
+		if s.Shuffle && s.Pick > 0 {
+			group = fmt.Sprintf("shuffle %d%s", s.Pick, group)
+		} else if s.Shuffle {
+			group = "shuffle" + group
+		}
+		switch {
+		case s.Repeat > 0 && s.Shuffle:
+			return strconv.Itoa(s.Repeat) + "x " + group
+		case s.Repeat > 0:
+			return strconv.Itoa(s.Repeat) + "x" + group
+		}
+		return group
+	}
+	duration := formatDuration(s.Duration)
+	if s.MaxDuration != 0 {
+		duration += ".." + formatDuration(s.MaxDuration)
+	}
+	step := duration + " " + string(s.Phase)
+	if s.Name != "" {
+		step += " " + strconv.Quote(s.Name)
+	}
+	for keyword, name := range restKeywords {
+		if s.Phase == Rest && s.Name == name {
+			step = keyword + " " + duration
+		}
+	}
+	if m := s.Metronome; m != nil {
+		step += " @" + strconv.FormatFloat(m.StartBPM, 'f', -1, 64)
+		if m.EndBPM != 0 {
+			step += ".." + strconv.FormatFloat(m.EndBPM, 'f', -1, 64)
+		}
+	}
+	if s.Repeat > 0 {
+		return strconv.Itoa(s.Repeat) + "x " + step
+	}
+	return step
+}
+
+// formatDuration writes d without trailing zero units, such as "5m" rather
+// than "5m0s".
+func formatDuration(d Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokNumber
+	tokWord
+	tokString
+	tokLParen
+	tokRParen
+	tokSep
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int // Byte offset in the source
+}
+
+type parser struct {
+	src string
+	pos int
+	tok token
+	cap Duration // Time cap, 0 until one is parsed
+}
+
+func (p *parser) errorf(pos int, format string, args ...interface{}) error {
+	line := strings.Count(p.src[:pos], "\n") + 1
+	column := utf8.RuneCountInString(p.src[strings.LastIndex(p.src[:pos], "\n")+1:pos]) + 1
+	return &SyntaxError{Line: line, Column: column, Message: fmt.Sprintf(format, args...)}
+}
+
+// next reads the next token into p.tok. Unknown characters are returned as
+// single character word tokens for the parser to reject.
+func (p *parser) next() {
+	for p.pos < len(p.src) {
+		r, size := utf8.DecodeRuneInString(p.src[p.pos:])
+		if !unicode.IsSpace(r) {
+			break
+		}
+		p.pos += size
+	}
+	start := p.pos
+	if p.pos == len(p.src) {
+		p.tok = token{kind: tokEOF, pos: start}
+		return
+	}
+	r, size := utf8.DecodeRuneInString(p.src[p.pos:])
+	switch {
+	case r == '(':
+		p.pos++
+		p.tok = token{kind: tokLParen, text: "(", pos: start}
+	case r == ')':
+		p.pos++
+		p.tok = token{kind: tokRParen, text: ")", pos: start}
+	case r == ';' || r == ',':
+		p.pos++
+		p.tok = token{kind: tokSep, text: string(r), pos: start}
+	case r == '"':
+		p.pos++
+		for p.pos < len(p.src) && p.src[p.pos] != '"' {
+			if p.src[p.pos] == '\\' {
+				p.pos++
+			}
+			p.pos++
+		}
+		if p.pos >= len(p.src) {
+			p.pos = len(p.src)
+			p.tok = token{kind: tokString, text: p.src[start:], pos: start}
+			return
+		}
+		p.pos++
+		p.tok = token{kind: tokString, text: p.src[start:p.pos], pos: start}
+	case r >= '0' && r <= '9' || r == '.':
+		p.pos = p.scan(func(r rune) bool { return r >= '0' && r <= '9' || r == '.' || isLetter(r) })
+		p.tok = token{kind: tokNumber, text: p.src[start:p.pos], pos: start}
+	case isLetter(r):
+		p.pos = p.scan(isLetter)
+		p.tok = token{kind: tokWord, text: p.src[start:p.pos], pos: start}
+	default:
+		p.pos += size
+		p.tok = token{kind: tokWord, text: p.src[start:p.pos], pos: start}
+	}
+}
+
+func (p *parser) scan(accept func(rune) bool) int {
+	pos := p.pos
+	for pos < len(p.src) {
+		r, size := utf8.DecodeRuneInString(p.src[pos:])
+		if !accept(r) {
+			break
+		}
+		pos += size
+	}
+	return pos
+}
+
+func isLetter(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r == 'µ'
+}
+
+// items parses items separated by separators up to the end token. A
+// trailing separator is allowed.
+func (p *parser) items(end tokenKind) ([]Segment, error) {
+	segments := []Segment{}
+	for {
+		switch p.tok.kind {
+		case tokEOF:
+			if end == tokRParen {
+				// The caller reports the unclosed group.
+				return segments, nil
+			}
+			fallthrough
+		case tokRParen, tokSep:
+			return nil, p.errorf(p.tok.pos, "expected a step or group, found %s", p.describe())
+		}
+		if p.tok.kind == tokWord && strings.ToLower(p.tok.text) == "cap" {
+			if err := p.timeCap(end); err != nil {
+				return nil, err
+			}
+		} else {
+			segment, err := p.item()
+			if err != nil {
+				return nil, err
+			}
+			segments = append(segments, segment)
+		}
+		switch p.tok.kind {
+		case tokSep:
+			p.next()
+			if p.tok.kind == end {
+				return segments, nil
+			}
+		case end, tokEOF:
+			return segments, nil
+		default:
+			if end == tokRParen {
+				return nil, p.errorf(p.tok.pos, "expected \",\", \";\" or \")\", found %s", p.describe())
+			}
+			return nil, p.errorf(p.tok.pos, "expected \",\" or \";\", found %s", p.describe())
+		}
+	}
+}
+
+func (p *parser) item() (Segment, error) {
+	repeat, err := p.repeat()
+	if err != nil {
+		return Segment{}, err
+	}
+	shuffle, pick, pickPos := false, 0, 0
+	if p.tok.kind == tokWord && strings.ToLower(p.tok.text) == "shuffle" {
+		shuffle = true
+		p.next()
+		if p.tok.kind == tokNumber {
+			pickPos = p.tok.pos
+			if pick, err = strconv.Atoi(p.tok.text); err != nil || !isInteger(p.tok.text) || pick < 1 {
+				return Segment{}, p.errorf(p.tok.pos, "invalid number of segments to pick %q", p.tok.text)
+			}
+			p.next()
+		}
+		if p.tok.kind != tokLParen {
+			return Segment{}, p.errorf(p.tok.pos, "expected \"(\" after shuffle, found %s", p.describe())
+		}
+	}
+	if p.tok.kind == tokLParen {
+		open := p.tok.pos
+		p.next()
+		segments, err := p.items(tokRParen)
+		if err != nil {
+			return Segment{}, err
+		}
+		if p.tok.kind != tokRParen {
+			return Segment{}, p.errorf(open, "unclosed \"(\"")
+		}
+		if pick > len(segments) {
+			return Segment{}, p.errorf(pickPos, "can't pick %d of %d segments", pick, len(segments))
+		}
+		p.next()
+		return Segment{Repeat: repeat, Segments: segments, Shuffle: shuffle, Pick: pick}, nil
+	}
+	segment, err := p.step()
+	segment.Repeat = repeat
+	return segment, err
+}
+
+// timeCap parses a time cap, such as "cap 15m", in items ending with end.
+func (p *parser) timeCap(end tokenKind) error {
+	tok := p.tok
+	switch {
+	case end != tokEOF:
+		return p.errorf(tok.pos, "a cap must be at the top level of the program, not in a group")
+	case p.cap != 0:
+		return p.errorf(tok.pos, "the program already has a cap")
+	}
+	p.next()
+	if p.tok.kind != tokNumber {
+		return p.errorf(p.tok.pos, "expected a duration after cap, found %s", p.describe())
+	}
+	if strings.Contains(p.tok.text, "..") {
+		return p.errorf(p.tok.pos, "a cap can't be a range")
+	}
+	limit, _, err := p.duration()
+	if err != nil {
+		return err
+	}
+	p.cap = limit
+	return nil
+}
+
+// repeat parses an optional repeat count, such as "3x" or "3 x".
+func (p *parser) repeat() (int, error) {
+	if p.tok.kind != tokNumber {
+		return 0, nil
+	}
+	tok := p.tok
+	text := tok.text
+	if strings.HasSuffix(strings.ToLower(text), "x") {
+		text = text[:len(text)-1]
+		p.next()
+	} else if !isInteger(text) {
+		return 0, nil
+	} else {
+		// Only a count if followed by "x", otherwise a number of seconds.
+		saved := *p
+		p.next()
+		if p.tok.kind != tokWord || strings.ToLower(p.tok.text) != "x" {
+			*p = saved
+			return 0, nil
+		}
+		p.next()
+	}
+	count, err := strconv.Atoi(text)
+	if err != nil || !isInteger(text) {
+		return 0, p.errorf(tok.pos, "invalid repeat count %q", tok.text)
+	}
+	if count < 1 || count > MAX_REPEAT {
+		return 0, p.errorf(tok.pos, "repeat count must be between 1 and %d", MAX_REPEAT)
+	}
+	return count, nil
+}
+
+// step parses a duration and a phase or keyword, in either order, followed
+// by an optional name and metronome.
+func (p *parser) step() (Segment, error) {
+	segment := Segment{}
+	var haveDuration, haveKind bool
+	for i := 0; i < 2; i++ {
+		switch {
+		case p.tok.kind == tokNumber && !haveDuration:
+			min, max, err := p.duration()
+			if err != nil {
+				return segment, err
+			}
+			segment.Duration, segment.MaxDuration = min, max
+			haveDuration = true
+		case p.tok.kind == tokWord && !haveKind:
+			word := strings.ToLower(p.tok.text)
+			if name, ok := restKeywords[word]; ok {
+				segment.Phase, segment.Name = Rest, name
+			} else if Phase(word) == Work || Phase(word) == Rest {
+				segment.Phase = Phase(word)
+			} else if r, _ := utf8.DecodeRuneInString(p.tok.text); isLetter(r) {
+				return segment, p.errorf(p.tok.pos, "unknown phase %q, expected work, rest, prep, warmup or cooldown", p.tok.text)
+			} else {
+				return segment, p.errorf(p.tok.pos, "unexpected %q", p.tok.text)
+			}
+			haveKind = true
+			p.next()
+		case !haveDuration && !haveKind:
+			return segment, p.errorf(p.tok.pos, "expected a step or group, found %s", p.describe())
+		case !haveDuration:
+			return segment, p.errorf(p.tok.pos, "expected a duration, found %s", p.describe())
+		default:
+			return segment, p.errorf(p.tok.pos, "expected work or rest, found %s", p.describe())
+		}
+	}
+	if p.tok.kind == tokString {
+		name, err := strconv.Unquote(p.tok.text)
+		if err != nil {
+			if !strings.HasSuffix(p.tok.text, "\"") || len(p.tok.text) == 1 {
+				return segment, p.errorf(p.tok.pos, "unterminated name")
+			}
+			return segment, p.errorf(p.tok.pos, "invalid name %s", p.tok.text)
+		}
+		segment.Name = name
+		p.next()
+	}
+	if p.tok.kind == tokWord && p.tok.text == "@" {
+		p.next()
+		m, err := p.metronome()
+		if err != nil {
+			return segment, err
+		}
+		segment.Metronome = &m
+	}
+	return segment, nil
+}
+
+// metronome parses a tempo in beats per minute, or a ramp between two tempos
+// such as "20..28".
+func (p *parser) metronome() (Metronome, error) {
+	tok := p.tok
+	if tok.kind != tokNumber {
+		return Metronome{}, p.errorf(tok.pos, "expected a tempo in beats per minute, found %s", p.describe())
+	}
+	text, endText, isRamp := strings.Cut(tok.text, "..")
+	start, err := p.parseBPM(text, tok.pos)
+	if err != nil {
+		return Metronome{}, err
+	}
+	m := Metronome{StartBPM: start}
+	if isRamp {
+		if m.EndBPM, err = p.parseBPM(endText, tok.pos+len(text)+2); err != nil {
+			return Metronome{}, err
+		}
+		if m.StartBPM == 0 || m.EndBPM == 0 {
+			return Metronome{}, p.errorf(tok.pos, "a ramping tempo must be above 0 BPM")
+		}
+	}
+	p.next()
+	return m, nil
+}
+
+func (p *parser) parseBPM(text string, pos int) (float64, error) {
+	bpm, err := strconv.ParseFloat(text, 64)
+	if err != nil || bpm < 0 {
+		return 0, p.errorf(pos, "invalid tempo %q", text)
+	}
+	return bpm, nil
+}
+
+// duration parses a duration, or a range of durations such as "10s..30s" for
+// a randomized length.
+func (p *parser) duration() (Duration, Duration, error) {
+	tok := p.tok
+	text, maxText, isRange := strings.Cut(tok.text, "..")
+	min, err := p.parseDuration(text, tok.pos)
+	if err != nil {
+		return 0, 0, err
+	}
+	var max Duration
+	if isRange {
+		if max, err = p.parseDuration(maxText, tok.pos+len(text)+2); err != nil {
+			return 0, 0, err
+		}
+		if max <= min {
+			return 0, 0, p.errorf(tok.pos, "the longest duration must be after the shortest")
+		}
+	}
+	p.next()
+	return min, max, nil
+}
+
+func (p *parser) parseDuration(text string, pos int) (Duration, error) {
+	var d time.Duration
+	if isInteger(text) {
+		seconds, err := strconv.Atoi(text)
+		if err != nil || seconds > int(time.Duration(1<<63-1)/time.Second) {
+			return 0, p.errorf(pos, "duration %s is too long", text)
+		}
+		d = time.Duration(seconds) * time.Second
+	} else {
+		parsed, err := time.ParseDuration(text)
+		if err != nil {
+			return 0, p.errorf(pos, "invalid duration %q", text)
+		}
+		d = parsed
+	}
+	switch {
+	case d < time.Second:
+		return 0, p.errorf(pos, "duration must be at least 1s")
+	case d%time.Second != 0:
+		return 0, p.errorf(pos, "duration must be a whole number of seconds")
+	}
+	return Duration(d), nil
+}
+
+// describe returns the current token for use in an error message.
+func (p *parser) describe() string {
+	if p.tok.kind == tokEOF {
+		return "end of program"
+	}
+	return strconv.Quote(p.tok.text)
+}
+
+func isInteger(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
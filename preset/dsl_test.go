@@ -0,0 +1,176 @@
+package preset
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseProgram(t *testing.T) {
+	p, err := ParseProgram(`prep 10s; 3x(8x(20s work "Sprint", 10s rest), 2m rest); cooldown 5m`)
+	require.NoError(t, err)
+	assert.Equal(t, Program{Segments: []Segment{
+		{Name: "Prep", Phase: Rest, Duration: Duration(10 * time.Second)},
+		{Repeat: 3, Segments: []Segment{
+			{Repeat: 8, Segments: []Segment{
+				{Name: "Sprint", Phase: Work, Duration: Duration(20 * time.Second)},
+				{Phase: Rest, Duration: Duration(10 * time.Second)},
+			}},
+			{Phase: Rest, Duration: Duration(2 * time.Minute)},
+		}},
+		{Name: "Cool down", Phase: Rest, Duration: Duration(5 * time.Minute)},
+	}}, p)
+	assert.NoError(t, (&Preset{Version: Version, Program: p}).Validate())
+
+	p, err = ParseProgram("work 90; 2 x rest 1m30s \"Walk\",\n(warmup 1h)")
+	require.NoError(t, err)
+	assert.Equal(t, Program{Segments: []Segment{
+		{Phase: Work, Duration: Duration(90 * time.Second)},
+		{Name: "Walk", Phase: Rest, Duration: Duration(90 * time.Second), Repeat: 2},
+		{Segments: []Segment{{Name: "Warm up", Phase: Rest, Duration: Duration(time.Hour)}}},
+	}}, p)
+}
+
+func TestParseProgramErrors(t *testing.T) {
+	tests := []struct {
+		src          string
+		line, column int
+	}{
+		{"", 1, 1},
+		{"10s", 1, 4},
+		{"10s walk", 1, 5},
+		{"10s work 20s rest", 1, 10},
+		{"10s work; 0x(5s rest)", 1, 11},
+		{"10s work; 3x(5s rest", 1, 13},
+		{"10s work; 3x(5s rest))", 1, 22},
+		{"10s work; ()", 1, 12},
+		{"1.5s work", 1, 1},
+		{"10q work", 1, 1},
+		{"10s work \"Sprint", 1, 10},
+		{"10s work;\n  5s rest;\n  réveil 5s", 3, 3},
+		{"10s work; * 5s", 1, 11},
+	}
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			_, err := ParseProgram(tt.src)
+			var syntaxErr *SyntaxError
+			if assert.True(t, errors.As(err, &syntaxErr), "got %v", err) {
+				assert.Equal(t, tt.line, syntaxErr.Line, err.Error())
+				assert.Equal(t, tt.column, syntaxErr.Column, err.Error())
+			}
+		})
+	}
+}
+
+func TestProgramString(t *testing.T) {
+	src := `prep 10s; 3x(8x(20s work "Sprint", 10s rest), 2m rest); 2x 1h30m work; cooldown 5m`
+	p, err := ParseProgram(src)
+	require.NoError(t, err)
+	assert.Equal(t, src, p.String())
+
+	intervals := Program{Intervals: 3, Work: Duration(20 * time.Second), Rest: Duration(10 * time.Second), RestBeforeStart: true}
+	assert.Equal(t, "10s rest; 20s work; 2x(10s rest, 20s work)", intervals.String())
+	p, err = ParseProgram(intervals.String())
+	require.NoError(t, err)
+	assert.Equal(t, intervals.Flatten(), p.Flatten())
+}
+
+func TestParseSegmentMetronome(t *testing.T) {
+	src := `8x(20s work "Row" @24..28, 10s rest @0); 5m work @22.5`
+	p, err := ParseProgram(src)
+	require.NoError(t, err)
+	assert.Equal(t, Program{Segments: []Segment{
+		{Repeat: 8, Segments: []Segment{
+			{Name: "Row", Phase: Work, Duration: Duration(20 * time.Second), Metronome: &Metronome{StartBPM: 24, EndBPM: 28}},
+			{Phase: Rest, Duration: Duration(10 * time.Second), Metronome: &Metronome{}},
+		}},
+		{Phase: Work, Duration: Duration(5 * time.Minute), Metronome: &Metronome{StartBPM: 22.5}},
+	}}, p)
+	assert.Equal(t, src, p.String())
+	assert.Equal(t, &Metronome{StartBPM: 24, EndBPM: 28}, p.Flatten()[0].Metronome)
+	assert.NoError(t, (&Preset{Version: Version, Program: p}).Validate())
+
+	for _, src := range []string{"10s work @", "10s work @fast", "10s work @0..20", "10s work @-5", "10s work @20 \"Row\"", "3x(10s work) @20"} {
+		_, err := ParseProgram(src)
+		var syntaxErr *SyntaxError
+		assert.True(t, errors.As(err, &syntaxErr), "%q: got %v", src, err)
+	}
+}
+
+func FuzzParseProgram(f *testing.F) {
+	f.Add(`prep 10s; 3x(8x(20s work "Sprint", 10s rest), 2m rest); cooldown 5m`)
+	f.Add("work 90; 2 x rest 1m30s \"Walk\",\n(warmup 1h)")
+	f.Add(`(((1s work)));`)
+	f.Add(`5s rest "é\t\"quoted\""`)
+	f.Add(`3x shuffle 2(30s work "A", 30s work "B", 30s work "C"); 10s..30s rest`)
+	f.Add(`cap 15m; 10x(1m work, 30s rest)`)
+	f.Add(`8x(20s work "Row" @24..28, 10s rest @0)`)
+	f.Fuzz(func(t *testing.T, src string) {
+		p, err := ParseProgram(src)
+		if err != nil {
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("%q: error is not a *SyntaxError: %v", src, err)
+			}
+			return
+		}
+		if err := (&Preset{Version: Version, Program: p}).Validate(); err != nil {
+			t.Fatalf("%q: parsed program is invalid: %v", src, err)
+		}
+		printed := p.String()
+		reparsed, err := ParseProgram(printed)
+		if err != nil {
+			t.Fatalf("%q printed as %q which doesn't parse: %v", src, printed, err)
+		}
+		assert.Equal(t, p, reparsed)
+		assert.Equal(t, printed, reparsed.String())
+	})
+}
+
+func TestParseRandomProgram(t *testing.T) {
+	src := `3x shuffle 2(30s work "Burpees", 30s work "Rows", 30s work "Squats"); 10s..30s rest; shuffle(1m work, 2m work)`
+	p, err := ParseProgram(src)
+	require.NoError(t, err)
+	assert.Equal(t, Program{Segments: []Segment{
+		{Repeat: 3, Shuffle: true, Pick: 2, Segments: []Segment{
+			{Name: "Burpees", Phase: Work, Duration: Duration(30 * time.Second)},
+			{Name: "Rows", Phase: Work, Duration: Duration(30 * time.Second)},
+			{Name: "Squats", Phase: Work, Duration: Duration(30 * time.Second)},
+		}},
+		{Phase: Rest, Duration: Duration(10 * time.Second), MaxDuration: Duration(30 * time.Second)},
+		{Shuffle: true, Segments: []Segment{
+			{Phase: Work, Duration: Duration(time.Minute)},
+			{Phase: Work, Duration: Duration(2 * time.Minute)},
+		}},
+	}}, p)
+	assert.Equal(t, src, p.String())
+
+	for _, src := range []string{"30s..10s rest", "shuffle 10s work", "shuffle 3(1s work, 2s work)", "shuffle 0(1s work)"} {
+		_, err := ParseProgram(src)
+		var syntaxErr *SyntaxError
+		assert.True(t, errors.As(err, &syntaxErr), "%q: got %v", src, err)
+	}
+}
+
+func TestParseTimeCap(t *testing.T) {
+	src := `cap 15m; 10x(1m work, 30s rest)`
+	p, err := ParseProgram(src)
+	require.NoError(t, err)
+	assert.Equal(t, Duration(15*time.Minute), p.Cap)
+	assert.Len(t, p.Segments, 1)
+	assert.Equal(t, src, p.String())
+
+	p, err = ParseProgram(`20s work; CAP 90`)
+	require.NoError(t, err)
+	assert.Equal(t, Duration(90*time.Second), p.Cap)
+	assert.Equal(t, `cap 1m30s; 20s work`, p.String())
+
+	for _, src := range []string{"cap 15m", "cap; 10s work", "cap 1m; cap 2m; 10s work", "3x(cap 1m, 10s work)", "cap 10s..20s; 10s work"} {
+		_, err := ParseProgram(src)
+		var syntaxErr *SyntaxError
+		assert.True(t, errors.As(err, &syntaxErr), "%q: got %v", src, err)
+	}
+}
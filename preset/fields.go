@@ -0,0 +1,169 @@
+package preset
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// fieldChecker reports the fields of a decoded document that its type
+// doesn't have or that have the wrong type, with paths written as Validate
+// writes them.
+type fieldChecker struct {
+	lines map[string]int // Line of each field path in a YAML document, empty for JSON
+	errs  ValidationErrors
+}
+
+func (c *fieldChecker) add(field, format string, args ...interface{}) {
+	c.errs = append(c.errs, ValidationError{Field: field, Line: c.line(field), Message: fmt.Sprintf(format, args...)})
+}
+
+// line returns the line of field in the document, or of the closest field
+// containing it if field was added by a migration.
+func (c *fieldChecker) line(field string) int {
+	for field != "" {
+		if line, ok := c.lines[field]; ok {
+			return line
+		}
+		field = field[:strings.LastIndexAny(field, ".[")+1]
+		field = strings.TrimRight(field, ".[")
+	}
+	return 0
+}
+
+var (
+	durationType = reflect.TypeOf(Duration(0))
+	timeType     = reflect.TypeOf(time.Time{})
+)
+
+// check checks the value v of field against t.
+func (c *fieldChecker) check(field string, v interface{}, t reflect.Type) {
+	if v == nil {
+		return
+	}
+	if t == durationType {
+		switch v := v.(type) {
+		case int, float64:
+			return
+		case string:
+			if _, err := time.ParseDuration(v); err == nil {
+				return
+			}
+		}
+		c.add(field, "must be a duration")
+		return
+	}
+	if t == timeType {
+		switch v := v.(type) {
+		case time.Time:
+			return
+		case string:
+			if _, err := time.Parse(time.RFC3339, v); err == nil {
+				return
+			}
+		}
+		c.add(field, "must be a time, such as %q", "2024-03-09T18:00:00Z")
+		return
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		c.check(field, v, t.Elem())
+	case reflect.Struct:
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			c.add(field, "must be an object")
+			return
+		}
+		c.object(field, obj, t)
+	case reflect.Slice:
+		items, ok := v.([]interface{})
+		if !ok {
+			c.add(field, "must be a list")
+			return
+		}
+		for i, item := range items {
+			c.check(fmt.Sprintf("%s[%d]", field, i), item, t.Elem())
+		}
+	case reflect.String:
+		if _, ok := v.(string); !ok {
+			c.add(field, "must be a string")
+		}
+	case reflect.Bool:
+		if _, ok := v.(bool); !ok {
+			c.add(field, "must be a bool")
+		}
+	case reflect.Int:
+		if _, ok := versionOf(v); !ok {
+			c.add(field, "must be a whole number")
+		}
+	case reflect.Float64:
+		switch v.(type) {
+		case int, float64:
+		default:
+			c.add(field, "must be a number")
+		}
+	}
+}
+
+// object checks each field of obj against the struct type t.
+func (c *fieldChecker) object(field string, obj map[string]interface{}, t reflect.Type) {
+	fields := map[string]reflect.Type{}
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		fields[name] = t.Field(i).Type
+	}
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		v, f := obj[name], name
+		if field != "" {
+			f = field + "." + name
+		}
+		if ft, ok := fields[name]; ok {
+			c.check(f, v, ft)
+		} else {
+			c.add(f, "is not a known field")
+		}
+	}
+}
+
+// yamlLines returns the line of each field path in a YAML document.
+func yamlLines(data []byte) map[string]int {
+	root := yaml.Node{}
+	if err := yaml.Unmarshal(data, &root); err != nil || len(root.Content) == 0 {
+		return nil
+	}
+	lines := map[string]int{}
+	var walk func(field string, n *yaml.Node)
+	walk = func(field string, n *yaml.Node) {
+		if n.Kind == yaml.AliasNode {
+			n = n.Alias
+		}
+		switch n.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				f := n.Content[i].Value
+				if field != "" {
+					f = field + "." + f
+				}
+				lines[f] = n.Content[i].Line
+				walk(f, n.Content[i+1])
+			}
+		case yaml.SequenceNode:
+			for i, item := range n.Content {
+				f := fmt.Sprintf("%s[%d]", field, i)
+				lines[f] = item.Line
+				walk(f, item)
+			}
+		}
+	}
+	walk("", root.Content[0])
+	return lines
+}
@@ -0,0 +1,215 @@
+package preset
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// History is a record of the runs of the timer, oldest first.
+type History struct {
+	Version int   `json:"version" yaml:"version"`
+	Runs    []Run `json:"runs" yaml:"runs"`
+}
+
+// Run is a run of the timer recorded in a History.
+type Run struct {
+	Started  time.Time `json:"started" yaml:"started"`
+	Elapsed  Duration  `json:"elapsed" yaml:"elapsed"`                       // Time spent running, excluding pauses
+	Finished bool      `json:"finished,omitempty" yaml:"finished,omitempty"` // Whether the run reached the end of its program rather than being stopped
+	Preset   Preset    `json:"preset" yaml:"preset"`                         // Settings the run used, so that it can be run again
+}
+
+// HistorySchema is the schema of history documents. Each run embeds the
+// preset it ran, so the history schema moves in step with PresetSchema and
+// each of its migrations upgrades the preset of every run.
+var HistorySchema = Schema{
+	Name:       "history",
+	Version:    PresetSchema.Version,
+	Migrations: eachRun(PresetSchema.Migrations),
+}
+
+// eachRun returns migrations that apply the preset migrations to the preset
+// of each run in a history.
+func eachRun(migrations []Migration) []Migration {
+	runMigrations := make([]Migration, len(migrations))
+	for i, migration := range migrations {
+		migration := migration
+		runMigrations[i] = Migration{
+			From:        migration.From,
+			Description: "runs[].preset: " + migration.Description,
+			Apply: func(doc map[string]interface{}) error {
+				runs, _ := doc["runs"].([]interface{})
+				for j, run := range runs {
+					// runs that aren't objects are reported when the
+					// history is decoded
+					run, _ := run.(map[string]interface{})
+					p, ok := run["preset"].(map[string]interface{})
+					if !ok {
+						continue
+					}
+					if err := migration.Apply(p); err != nil {
+						return inField(fmt.Sprintf("runs[%d].preset", j), err)
+					}
+					p["version"] = migration.From + 1
+				}
+				return nil
+			},
+		}
+	}
+	return runMigrations
+}
+
+// NewHistory returns an empty history at the current version.
+func NewHistory() *History {
+	return &History{Version: HistorySchema.Version}
+}
+
+// Add records a run at the end of the history, keeping at most max runs by
+// dropping the oldest. A max of 0 keeps every run.
+func (h *History) Add(run Run, max int) {
+	h.Runs = append(h.Runs, run)
+	if max > 0 && len(h.Runs) > max {
+		h.Runs = append([]Run{}, h.Runs[len(h.Runs)-max:]...)
+	}
+}
+
+// Validate returns a ValidationErrors listing every invalid field in the
+// history, or nil if it is valid.
+func (h *History) Validate() error {
+	v := &validator{}
+	switch {
+	case h.Version == 0:
+		v.add("version", "is required")
+	case h.Version > HistorySchema.Version:
+		v.add("version", "%d is newer than the supported version %d", h.Version, HistorySchema.Version)
+	case h.Version < 0:
+		v.add("version", "must be positive")
+	}
+	for i, run := range h.Runs {
+		field := fmt.Sprintf("runs[%d]", i)
+		if run.Started.IsZero() {
+			v.add(field+".started", "is required")
+		}
+		if run.Elapsed < 0 {
+			v.add(field+".elapsed", "must not be negative")
+		}
+		var errs ValidationErrors
+		if errors.As(inField(field+".preset", run.Preset.Validate()), &errs) {
+			v.errs = append(v.errs, errs...)
+		}
+	}
+	if len(v.errs) > 0 {
+		return v.errs
+	}
+	return nil
+}
+
+// inField returns err with the fields of any ValidationErrors in it moved
+// under field.
+func inField(field string, err error) error {
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		return err
+	}
+	moved := make(ValidationErrors, len(errs))
+	for i, e := range errs {
+		e.Field = field + "." + e.Field
+		moved[i] = e
+	}
+	return moved
+}
+
+// LoadHistoryReport reads a history document in format from r, upgrades it
+// to the current version and validates it. The returned report lists the
+// migrations applied.
+func LoadHistoryReport(r io.Reader, format Format) (*History, *MigrationReport, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading history: %w", err)
+	}
+	h := &History{}
+	report, err := loadDocument(data, format, HistorySchema, h)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err = h.Validate(); err != nil {
+		return nil, nil, err
+	}
+	return h, report, nil
+}
+
+// LoadHistory reads a history document in format from r, upgrades it to the
+// current version and validates it. Returns a ValidationErrors if the
+// history is invalid.
+func LoadHistory(r io.Reader, format Format) (*History, error) {
+	h, _, err := LoadHistoryReport(r, format)
+	return h, err
+}
+
+// LoadHistoryFile reads and validates the history file at path. The format
+// is determined by the file's extension.
+func LoadHistoryFile(path string) (*History, error) {
+	format, err := FormatOf(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return LoadHistory(f, format)
+}
+
+// Save validates the history and writes it to w in format.
+func (h *History) Save(w io.Writer, format Format) error {
+	if err := h.Validate(); err != nil {
+		return err
+	}
+	switch format {
+	case JSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "\t")
+		return encoder.Encode(h)
+	case YAML:
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(h); err != nil {
+			return err
+		}
+		return encoder.Close()
+	}
+	return fmt.Errorf("unknown history format %d", format)
+}
+
+// SaveFile validates the history and writes it to the file at path. The
+// format is determined by the file's extension.
+func (h *History) SaveFile(path string) error {
+	format, err := FormatOf(path)
+	if err != nil {
+		return err
+	}
+	buf := &bytes.Buffer{}
+	if err = h.Save(buf, format); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// isHistory returns whether data in format is a history document rather
+// than a preset.
+func isHistory(data []byte, format Format) bool {
+	doc, err := parseDocument(data, format, "document")
+	if err != nil {
+		return false
+	}
+	_, runs := doc["runs"]
+	return runs
+}
@@ -0,0 +1,93 @@
+package preset
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistoryRoundTrip(t *testing.T) {
+	h := NewHistory()
+	h.Add(Run{Started: time.Date(2024, time.March, 9, 18, 0, 0, 0, time.UTC), Elapsed: Duration(10 * time.Minute), Finished: true, Preset: *sprints()}, 0)
+	h.Add(Run{Started: time.Date(2024, time.March, 10, 7, 0, 0, 0, time.UTC), Elapsed: Duration(90 * time.Second), Preset: *sprints()}, 0)
+	for _, format := range []Format{JSON, YAML} {
+		buf := &bytes.Buffer{}
+		require.NoError(t, h.Save(buf, format))
+		loaded, err := LoadHistory(buf, format)
+		require.NoError(t, err)
+		assert.Equal(t, h, loaded)
+	}
+}
+
+func TestHistoryAddKeepsNewest(t *testing.T) {
+	h := NewHistory()
+	for day := 1; day <= 3; day++ {
+		h.Add(Run{Started: time.Date(2024, time.March, day, 7, 0, 0, 0, time.UTC), Preset: *sprints()}, 2)
+	}
+	require.Len(t, h.Runs, 2)
+	assert.Equal(t, 2, h.Runs[0].Started.Day())
+	assert.Equal(t, 3, h.Runs[1].Started.Day())
+}
+
+func TestMigrateHistoryVersion1(t *testing.T) {
+	doc := `{"version": 1, "runs": [
+		{"started": "2024-03-09T18:00:00Z", "elapsed": "10m", "finished": true, "preset": {"version": 1, "program": {"intervals": 2, "work": "20s"}, "cues": {"alarm": true}}},
+		{"started": "2024-03-10T07:00:00Z", "elapsed": "90s", "preset": {"version": 1, "program": {"intervals": 2, "work": "20s"}}}
+	]}`
+	h, report, err := LoadHistoryReport(strings.NewReader(doc), JSON)
+	require.NoError(t, err)
+	assert.True(t, report.Changed())
+	assert.Equal(t, "history", report.Schema)
+	assert.Len(t, report.Applied, 1)
+	assert.Equal(t, Version, h.Version)
+	assert.Equal(t, Version, h.Runs[0].Preset.Version)
+	assert.Equal(t, Alarm{Enabled: true}, h.Runs[0].Preset.Cues.Alarm)
+	assert.Equal(t, Duration(90*time.Second), h.Runs[1].Elapsed)
+
+	_, err = LoadHistory(strings.NewReader(`{"version": 1, "runs": [
+		{"started": "2024-03-09T18:00:00Z", "preset": {"version": 1, "program": {"intervals": 2, "work": "20s"}}},
+		{"started": "2024-03-10T07:00:00Z", "preset": {"version": 1, "program": {"intervals": 2, "work": "20s"}, "cues": {"alarm": "loud"}}}
+	]}`), JSON)
+	var errs ValidationErrors
+	if assert.True(t, errors.As(err, &errs), "got %v", err) {
+		assert.Equal(t, "runs[1].preset.cues.alarm", errs[0].Field)
+	}
+}
+
+func TestHistoryFieldErrors(t *testing.T) {
+	doc := "version: 2\nruns:\n  - started: 2024-03-09T18:00:00Z\n    elapsed: long\n    preset: {version: 2, program: {intervals: 2, work: 20s}}\n"
+	_, err := LoadHistory(strings.NewReader(doc), YAML)
+	var errs ValidationErrors
+	if assert.True(t, errors.As(err, &errs), "got %v", err) {
+		assert.Equal(t, "line 4: runs[0].elapsed: must be a duration", errs[0].Error())
+	}
+
+	_, err = LoadHistory(strings.NewReader(`{"version": 2, "runs": [{"started": "2024-03-09T18:00:00Z", "preset": {"version": 2, "program": {}}}]}`), JSON)
+	if assert.True(t, errors.As(err, &errs), "got %v", err) {
+		assert.True(t, strings.HasPrefix(errs[0].Field, "runs[0].preset.program"), "got %v", errs)
+	}
+}
+
+func TestMigrateHistoryFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.yaml")
+	old := "version: 1\nruns:\n  - started: 2024-03-09T18:00:00Z\n    elapsed: 10m\n    preset: {version: 1, program: {intervals: 2, work: 20s}, cues: {alarm: true}}\n"
+	require.NoError(t, os.WriteFile(path, []byte(old), 0644))
+
+	report, err := MigrateFile(path, false)
+	require.NoError(t, err)
+	assert.True(t, report.Changed())
+	assert.Equal(t, "history", report.Schema)
+	h, err := LoadHistoryFile(path)
+	require.NoError(t, err)
+	assert.True(t, h.Runs[0].Preset.Cues.Alarm.Enabled)
+	report, err = MigrateFile(path, true)
+	require.NoError(t, err)
+	assert.False(t, report.Changed())
+}
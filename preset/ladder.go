@@ -0,0 +1,90 @@
+package preset
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Ladder generates a program of work intervals whose length changes by Step
+// each round, such as 30s, 40s, 50s. A pyramid climbs back down after the
+// longest round, such as 30s, 40s, 50s, 40s, 30s. Work intervals are
+// separated by rests of a fixed length or of a length proportional to the
+// work before them.
+type Ladder struct {
+	Name      string   `json:"name,omitempty" yaml:"name,omitempty"` // Name of the work intervals
+	Rounds    int      `json:"rounds" yaml:"rounds"`                 // Number of work intervals up to and including the longest
+	Work      Duration `json:"work" yaml:"work"`                     // Length of the first work interval
+	Step      Duration `json:"step" yaml:"step"`                     // Change in length of each round, may be negative
+	Pyramid   bool     `json:"pyramid,omitempty" yaml:"pyramid,omitempty"`
+	Rest      Duration `json:"rest,omitempty" yaml:"rest,omitempty"`           // Length of each rest when RestRatio is zero
+	RestRatio float64  `json:"restRatio,omitempty" yaml:"restRatio,omitempty"` // Length of each rest as a multiple of the work before it, rounded to whole seconds
+}
+
+// Works returns the lengths of the ladder's work intervals in order.
+func (l Ladder) Works() []Duration {
+	works := []Duration{}
+	for i := 0; i < l.Rounds; i++ {
+		works = append(works, l.Work+Duration(i)*l.Step)
+	}
+	if l.Pyramid {
+		for i := l.Rounds - 2; i >= 0; i-- {
+			works = append(works, works[i])
+		}
+	}
+	return works
+}
+
+// RestAfter returns the length of the rest after a work interval of length
+// work.
+func (l Ladder) RestAfter(work Duration) Duration {
+	if l.RestRatio == 0 {
+		return l.Rest
+	}
+	seconds := math.Round(time.Duration(work).Seconds() * l.RestRatio)
+	return Duration(time.Duration(seconds) * time.Second)
+}
+
+// Segments returns the ladder as segments, with a rest between each pair of
+// work intervals. Rests shorter than a second are left out.
+func (l Ladder) Segments() []Segment {
+	segments := []Segment{}
+	works := l.Works()
+	for i, work := range works {
+		if i > 0 {
+			if rest := l.RestAfter(works[i-1]); rest >= Duration(time.Second) {
+				segments = append(segments, Segment{Phase: Rest, Duration: rest})
+			}
+		}
+		segments = append(segments, Segment{Name: l.Name, Phase: Work, Duration: work})
+	}
+	return segments
+}
+
+func (v *validator) ladder(field string, l Ladder) {
+	if l.Rounds <= 0 {
+		v.add(field+".rounds", "must be positive")
+	}
+	v.duration(field+".work", l.Work, true)
+	if time.Duration(l.Step)%time.Second != 0 {
+		v.add(field+".step", "must be a whole number of seconds")
+	} else if last := l.Work + Duration(l.Rounds-1)*l.Step; l.Rounds > 0 && last < Duration(time.Second) {
+		v.add(field+".step", "makes round %d shorter than 1s", l.Rounds)
+	}
+	v.duration(field+".rest", l.Rest, false)
+	switch {
+	case l.RestRatio < 0:
+		v.add(field+".restRatio", "must not be negative")
+	case l.RestRatio > 0 && l.Rest != 0:
+		v.add(field, "must have either rest or restRatio, not both")
+	}
+}
+
+// String describes the ladder, such as "ladder of 3 rounds from 30s by 10s".
+func (l Ladder) String() string {
+	kind := "ladder"
+	if l.Pyramid {
+		kind = "pyramid"
+	}
+	return fmt.Sprintf("%s of %d rounds from %s by %s", kind, l.Rounds, formatDuration(l.Work), formatDuration(l.Step))
+}
@@ -0,0 +1,62 @@
+package preset
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func seconds(s ...int) []Duration {
+	durations := make([]Duration, len(s))
+	for i, n := range s {
+		durations[i] = Duration(time.Duration(n) * time.Second)
+	}
+	return durations
+}
+
+func TestLadder(t *testing.T) {
+	ladder := Ladder{Rounds: 3, Work: Duration(30 * time.Second), Step: Duration(10 * time.Second), Rest: Duration(15 * time.Second)}
+	assert.Equal(t, seconds(30, 40, 50), ladder.Works())
+	assert.Equal(t, "30s work; 15s rest; 40s work; 15s rest; 50s work", Program{Ladder: &ladder}.String())
+
+	ladder.Pyramid = true
+	assert.Equal(t, seconds(30, 40, 50, 40, 30), ladder.Works())
+
+	ladder.Rest, ladder.RestRatio = 0, 0.5
+	durations := []Duration{}
+	for _, step := range (Program{Ladder: &ladder}).Flatten() {
+		durations = append(durations, step.Duration)
+	}
+	assert.Equal(t, seconds(30, 15, 40, 20, 50, 25, 40, 20, 30), durations)
+
+	descending := Ladder{Rounds: 4, Work: Duration(time.Minute), Step: Duration(-15 * time.Second)}
+	assert.Equal(t, seconds(60, 45, 30, 15), descending.Works())
+	assert.Len(t, descending.Segments(), 4, "rests of zero length are left out")
+}
+
+func TestLadderPreset(t *testing.T) {
+	p := New("Pyramid")
+	p.Program.Ladder = &Ladder{Rounds: 3, Work: Duration(30 * time.Second), Step: Duration(15 * time.Second), Pyramid: true, RestRatio: 1}
+	for _, format := range []Format{JSON, YAML} {
+		buf := &bytes.Buffer{}
+		require.NoError(t, p.Save(buf, format))
+		loaded, err := Load(buf, format)
+		require.NoError(t, err)
+		assert.Equal(t, p, loaded)
+	}
+
+	p.Program.Ladder = &Ladder{Rounds: 3, Work: Duration(30 * time.Second), Step: Duration(-20 * time.Second), Rest: Duration(time.Second), RestRatio: 1}
+	p.Program.Intervals = 2
+	var errs ValidationErrors
+	if assert.True(t, errors.As(p.Validate(), &errs)) {
+		fields := []string{}
+		for _, err := range errs {
+			fields = append(fields, err.Field)
+		}
+		assert.Equal(t, []string{"program", "program.ladder.step", "program.ladder"}, fields)
+	}
+}
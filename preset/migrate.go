@@ -0,0 +1,236 @@
+package preset
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Migration upgrades a document from version From of a schema to version
+// From+1.
+type Migration struct {
+	From        int
+	Description string
+	Apply       func(doc map[string]interface{}) error
+}
+
+// Schema is a versioned document format and the chain of migrations that
+// upgrade documents written with older versions of it.
+type Schema struct {
+	Name       string
+	Version    int         // Current version
+	Migrations []Migration // Migrations[i] upgrades version i+1 to version i+2
+}
+
+// PresetSchema is the schema of preset documents.
+var PresetSchema = Schema{
+	Name:    "preset",
+	Version: Version,
+	Migrations: []Migration{
+		{From: 1, Description: "cues.alarm changed from a bool to an object with enabled, snooze and maxDuration", Apply: migrateAlarm},
+	},
+}
+
+// MigrationReport describes the migrations applied to a document.
+type MigrationReport struct {
+	Schema  string
+	From    int      // Version the document was written with
+	To      int      // Version the document was upgraded to
+	Applied []string // Descriptions of the migrations applied, in order
+}
+
+// Changed returns whether the document was written with an older version and
+// upgraded.
+func (r *MigrationReport) Changed() bool {
+	return r.From != r.To
+}
+
+func (r *MigrationReport) String() string {
+	if !r.Changed() {
+		return fmt.Sprintf("%s is up to date at version %d", r.Schema, r.To)
+	}
+	lines := []string{fmt.Sprintf("%s upgraded from version %d to %d:", r.Schema, r.From, r.To)}
+	for i, applied := range r.Applied {
+		lines = append(lines, fmt.Sprintf("  %d -> %d: %s", r.From+i, r.From+i+1, applied))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Migrate upgrades doc in place to the current version of the schema by
+// applying each migration from the version it was written with. A document
+// without a version or written with a newer version is rejected with a
+// ValidationErrors.
+func (s Schema) Migrate(doc map[string]interface{}) (*MigrationReport, error) {
+	version, ok := versionOf(doc["version"])
+	switch {
+	case doc["version"] == nil:
+		return nil, ValidationErrors{{Field: "version", Message: "is required"}}
+	case !ok || version < 1:
+		return nil, ValidationErrors{{Field: "version", Message: "must be a positive whole number"}}
+	case version > s.Version:
+		return nil, ValidationErrors{{Field: "version", Message: fmt.Sprintf("%d is newer than the supported version %d", version, s.Version)}}
+	}
+	report := &MigrationReport{Schema: s.Name, From: version, To: version}
+	for report.To < s.Version {
+		migration := s.Migrations[report.To-1]
+		if err := migration.Apply(doc); err != nil {
+			return nil, fmt.Errorf("upgrading %s from version %d: %w", s.Name, report.To, err)
+		}
+		report.Applied = append(report.Applied, migration.Description)
+		report.To++
+	}
+	doc["version"] = report.To
+	return report, nil
+}
+
+func versionOf(v interface{}) (int, bool) {
+	switch v := v.(type) {
+	case int:
+		return v, true
+	case float64:
+		return int(v), v == float64(int(v))
+	}
+	return 0, false
+}
+
+// LoadReport reads a preset document in format from r, upgrades it to the
+// current version and validates it. The returned report lists the
+// migrations applied.
+func LoadReport(r io.Reader, format Format) (*Preset, *MigrationReport, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading preset: %w", err)
+	}
+	p := &Preset{}
+	report, err := loadDocument(data, format, PresetSchema, p)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err = p.Validate(); err != nil {
+		return nil, nil, err
+	}
+	return p, report, nil
+}
+
+// loadDocument reads a document of schema in format from data, upgrades it
+// to the current version and decodes it into v, which points to the struct
+// the schema describes.
+func loadDocument(data []byte, format Format, schema Schema, v interface{}) (*MigrationReport, error) {
+	doc, err := parseDocument(data, format, schema.Name)
+	if err != nil {
+		return nil, err
+	}
+	report, err := schema.Migrate(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	// check the upgraded document's fields before decoding it so that
+	// unknown fields and fields of the wrong type are reported with their
+	// path and, for YAML, their line
+	checker := &fieldChecker{}
+	if format == YAML {
+		checker.lines = yamlLines(data)
+	}
+	checker.object("", doc, reflect.TypeOf(v).Elem())
+	if len(checker.errs) > 0 {
+		return nil, checker.errs
+	}
+	upgraded, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", schema.Name, err)
+	}
+	if err = json.Unmarshal(upgraded, v); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", schema.Name, err)
+	}
+	return report, nil
+}
+
+// parseDocument parses data in format into a generic document, naming the
+// kind of document in errors.
+func parseDocument(data []byte, format Format, kind string) (map[string]interface{}, error) {
+	doc := map[string]interface{}{}
+	var err error
+	switch format {
+	case JSON:
+		err = json.Unmarshal(data, &doc)
+	case YAML:
+		err = yaml.Unmarshal(data, &doc)
+	default:
+		return nil, fmt.Errorf("unknown %s format %d", kind, format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", kind, err)
+	}
+	return doc, nil
+}
+
+// MigrateFile upgrades the preset or history file at path to the current
+// version, rewriting it if it was written with an older version. With dryRun
+// the file is checked and the report returned without rewriting it.
+func MigrateFile(path string, dryRun bool) (*MigrationReport, error) {
+	format, err := FormatOf(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		report *MigrationReport
+		save   func(path string) error
+	)
+	if isHistory(data, format) {
+		var h *History
+		h, report, err = LoadHistoryReport(bytes.NewReader(data), format)
+		if h != nil {
+			save = h.SaveFile
+		}
+	} else {
+		var p *Preset
+		p, report, err = LoadReport(bytes.NewReader(data), format)
+		if p != nil {
+			save = p.SaveFile
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if dryRun || !report.Changed() {
+		return report, nil
+	}
+	if err = save(path); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return report, nil
+}
+
+// migrateAlarm replaces the version 1 "alarm": true cue with
+// "alarm": {"enabled": true}.
+func migrateAlarm(doc map[string]interface{}) error {
+	cues, ok := doc["cues"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	alarm, exists := cues["alarm"]
+	if !exists {
+		return nil
+	}
+	enabled, ok := alarm.(bool)
+	if !ok {
+		return ValidationErrors{{Field: "cues.alarm", Message: "must be a bool"}}
+	}
+	if enabled {
+		cues["alarm"] = map[string]interface{}{"enabled": true}
+	} else {
+		delete(cues, "alarm")
+	}
+	return nil
+}
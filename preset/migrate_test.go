@@ -0,0 +1,105 @@
+package preset
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrateVersion1(t *testing.T) {
+	docs := map[Format]string{
+		JSON: `{"version": 1, "program": {"intervals": 2, "work": "20s"}, "cues": {"countdownFrom": 3, "alarm": true}}`,
+		YAML: "version: 1\nprogram: {intervals: 2, work: 20s}\ncues:\n  countdownFrom: 3\n  alarm: true\n",
+	}
+	for format, doc := range docs {
+		p, report, err := LoadReport(strings.NewReader(doc), format)
+		require.NoError(t, err)
+		assert.Equal(t, Version, p.Version)
+		assert.Equal(t, Alarm{Enabled: true}, p.Cues.Alarm)
+		assert.Equal(t, 3, p.Cues.CountdownFrom)
+		assert.True(t, report.Changed())
+		assert.Equal(t, 1, report.From)
+		assert.Equal(t, Version, report.To)
+		assert.Len(t, report.Applied, 1)
+	}
+
+	p, err := Load(strings.NewReader(`{"version": 1, "program": {"intervals": 2, "work": "20s"}, "cues": {"alarm": false}}`), JSON)
+	require.NoError(t, err)
+	assert.Equal(t, Alarm{}, p.Cues.Alarm)
+
+	_, err = Load(strings.NewReader(`{"version": 1, "program": {"intervals": 2, "work": "20s"}, "cues": {"alarm": "loud"}}`), JSON)
+	var errs ValidationErrors
+	if assert.True(t, errors.As(err, &errs), "got %v", err) {
+		assert.Equal(t, "cues.alarm", errs[0].Field)
+	}
+}
+
+func TestMigrateCurrentVersion(t *testing.T) {
+	_, report, err := LoadReport(strings.NewReader(`{"version": 2, "program": {"intervals": 2, "work": "20s"}, "cues": {"alarm": {"enabled": true}}}`), JSON)
+	require.NoError(t, err)
+	assert.False(t, report.Changed())
+	assert.Empty(t, report.Applied)
+
+	_, err = Load(strings.NewReader(`{"version": 1.5, "program": {"intervals": 2, "work": "20s"}}`), JSON)
+	assert.Error(t, err)
+}
+
+func TestMigrateFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "old.yaml")
+	old := "version: 1\nprogram: {intervals: 2, work: 20s}\ncues: {alarm: true}\n"
+	require.NoError(t, os.WriteFile(path, []byte(old), 0644))
+
+	report, err := MigrateFile(path, true)
+	require.NoError(t, err)
+	assert.True(t, report.Changed())
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, old, string(data), "dry run rewrote the file")
+
+	_, err = MigrateFile(path, false)
+	require.NoError(t, err)
+	report, err = MigrateFile(path, true)
+	require.NoError(t, err)
+	assert.False(t, report.Changed())
+	p, err := LoadFile(path)
+	require.NoError(t, err)
+	assert.True(t, p.Cues.Alarm.Enabled)
+}
+
+func TestMigrateFieldErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		format Format
+		doc    string
+		want   []string
+	}{
+		{"json wrong type", JSON, `{"version": 1, "program": {"segments": [{"duration": "10s"}, {"duration": "10s"}, {"duration": "10s"}, {"duration": true}]}, "cues": {"alarm": true}}`,
+			[]string{"program.segments[3].duration: must be a duration"}},
+		{"json unknown field", JSON, `{"version": 1, "program": {"segments": [{"duration": "10s", "colour": "red"}]}}`,
+			[]string{"program.segments[0].colour: is not a known field"}},
+		{"yaml wrong type", YAML, "version: 1\nprogram:\n  segments:\n    - duration: 10s\n    - name: Rest\n      repeat: twice\ncues: {alarm: true}\n",
+			[]string{"line 6: program.segments[1].repeat: must be a whole number"}},
+		{"yaml unknown field", YAML, "version: 1\nprogram:\n  segments:\n    - duration: 10s\n      colour: red\n",
+			[]string{"line 5: program.segments[0].colour: is not a known field"}},
+		{"yaml migrated field", YAML, "version: 1\nprogram: {intervals: 2, work: 20s}\ncues:\n  alarm: true\n  volume: 1\n",
+			[]string{"line 5: cues.volume: is not a known field"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := LoadReport(strings.NewReader(tt.doc), tt.format)
+			var errs ValidationErrors
+			if assert.True(t, errors.As(err, &errs), "got %v", err) {
+				messages := []string{}
+				for _, e := range errs {
+					messages = append(messages, e.Error())
+				}
+				assert.Equal(t, tt.want, messages)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package preset
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Plan describes a workout by the time available rather than the length of
+// each interval. Every round works at each station once, with a rest
+// between each pair of work intervals.
+type Plan struct {
+	Budget    Duration // Total length of the program
+	WorkRatio float64  // Work part of the work to rest ratio, such as 3 in 3:1
+	RestRatio float64  // Rest part of the work to rest ratio, such as 1 in 3:1
+	Stations  int
+	Rounds    int      // Defaults to 1
+	WarmUp    Duration // Rest at the start of the program, optional
+	CoolDown  Duration // Rest at the end of the program, optional
+}
+
+// PlanResult is a program generated from a plan, with the length of its
+// work and rest intervals and the adjustments made to fit the budget in
+// whole seconds.
+type PlanResult struct {
+	Program     Program
+	Work        Duration
+	Rest        Duration
+	Adjustments []string
+}
+
+// ParseRatio parses a work to rest ratio written as "3:1".
+func ParseRatio(s string) (work, rest float64, err error) {
+	workText, restText, found := strings.Cut(s, ":")
+	if !found {
+		return 0, 0, fmt.Errorf("ratio %q must be written as work:rest, such as 3:1", s)
+	}
+	work, err = strconv.ParseFloat(strings.TrimSpace(workText), 64)
+	if err != nil || work <= 0 {
+		return 0, 0, fmt.Errorf("work in ratio %q must be a positive number", s)
+	}
+	rest, err = strconv.ParseFloat(strings.TrimSpace(restText), 64)
+	if err != nil || rest < 0 {
+		return 0, 0, fmt.Errorf("rest in ratio %q must not be negative", s)
+	}
+	return work, rest, nil
+}
+
+// Generate returns a program that fits the plan's budget exactly. Work and
+// rest lengths are rounded down to whole seconds and the seconds left over
+// are added to the cool down, or the warm up if there is no cool down, or
+// else spread one second at a time over the work intervals.
+func (p Plan) Generate() (*PlanResult, error) {
+	rounds := p.Rounds
+	if rounds == 0 {
+		rounds = 1
+	}
+	switch {
+	case p.Stations <= 0:
+		return nil, errors.New("the number of stations must be positive")
+	case rounds < 0:
+		return nil, errors.New("the number of rounds must be positive")
+	case p.WorkRatio <= 0 || p.RestRatio < 0:
+		return nil, errors.New("the work to rest ratio must be positive")
+	}
+	for _, d := range []Duration{p.Budget, p.WarmUp, p.CoolDown} {
+		if d < 0 || time.Duration(d)%time.Second != 0 {
+			return nil, errors.New("the budget, warm up and cool down must be whole numbers of seconds")
+		}
+	}
+	available := time.Duration(p.Budget - p.WarmUp - p.CoolDown)
+	works := rounds * p.Stations
+	rests := works - 1
+	if p.RestRatio == 0 {
+		rests = 0
+	}
+	if available < time.Duration(works+rests)*time.Second {
+		return nil, fmt.Errorf("%s isn't long enough for %d work intervals after the warm up and cool down", p.Budget, works)
+	}
+
+	// the length of one part of the ratio, in seconds
+	unit := available.Seconds() / (float64(works)*p.WorkRatio + float64(rests)*p.RestRatio)
+	exactWork, exactRest := unit*p.WorkRatio, unit*p.RestRatio
+	work := time.Duration(exactWork) * time.Second
+	rest := time.Duration(exactRest) * time.Second
+	if work < time.Second || (rests > 0 && rest < time.Second) {
+		return nil, fmt.Errorf("%s is too short for %d work intervals at a %g:%g ratio", p.Budget, works, p.WorkRatio, p.RestRatio)
+	}
+
+	result := &PlanResult{Work: Duration(work), Rest: Duration(rest)}
+	if exactWork != work.Seconds() {
+		result.Adjustments = append(result.Adjustments, fmt.Sprintf("work rounded down from %.1fs to %s", exactWork, formatDuration(result.Work)))
+	}
+	if rests > 0 && exactRest != rest.Seconds() {
+		result.Adjustments = append(result.Adjustments, fmt.Sprintf("rest rounded down from %.1fs to %s", exactRest, formatDuration(result.Rest)))
+	}
+
+	warmUp, coolDown := p.WarmUp, p.CoolDown
+	extraWorks := 0
+	leftover := available - time.Duration(works)*work - time.Duration(rests)*rest
+	switch {
+	case leftover == 0:
+	case coolDown > 0:
+		coolDown += Duration(leftover)
+		result.Adjustments = append(result.Adjustments, fmt.Sprintf("%s left over added to the cool down", formatDuration(Duration(leftover))))
+	case warmUp > 0:
+		warmUp += Duration(leftover)
+		result.Adjustments = append(result.Adjustments, fmt.Sprintf("%s left over added to the warm up", formatDuration(Duration(leftover))))
+	default:
+		// leftover is less than a second for each interval, spread it over
+		// the work intervals first
+		extraWorks = int(leftover / time.Second)
+		if extraWorks > works {
+			extraWorks = works
+		}
+	}
+	extraRests := 0
+	if leftover > 0 && warmUp == p.WarmUp && coolDown == p.CoolDown {
+		extraRests = int(leftover/time.Second) - extraWorks
+		if extraRests > 0 {
+			result.Adjustments = append(result.Adjustments, fmt.Sprintf("%s left over added 1s to %d work and %d rest intervals", formatDuration(Duration(leftover)), extraWorks, extraRests))
+		} else {
+			result.Adjustments = append(result.Adjustments, fmt.Sprintf("%s left over added 1s to %d work intervals", formatDuration(Duration(leftover)), extraWorks))
+		}
+	}
+
+	segments := []Segment{}
+	if warmUp > 0 {
+		segments = append(segments, Segment{Name: restKeywords["warmup"], Phase: Rest, Duration: warmUp})
+	}
+	for i := 0; i < works; i++ {
+		if i > 0 && rests > 0 {
+			length := rest
+			if i <= extraRests {
+				length += time.Second
+			}
+			segments = append(segments, Segment{Phase: Rest, Duration: Duration(length)})
+		}
+		length := work
+		if i < extraWorks {
+			length += time.Second
+		}
+		segments = append(segments, Segment{Name: fmt.Sprintf("Station %d", i%p.Stations+1), Phase: Work, Duration: Duration(length)})
+	}
+	if coolDown > 0 {
+		segments = append(segments, Segment{Name: restKeywords["cooldown"], Phase: Rest, Duration: coolDown})
+	}
+	result.Program = Program{Segments: segments}
+	return result, nil
+}
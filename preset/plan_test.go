@@ -0,0 +1,85 @@
+package preset
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func total(steps []Segment) time.Duration {
+	var total time.Duration
+	for _, step := range steps {
+		total += time.Duration(step.Duration)
+	}
+	return total
+}
+
+func TestPlan(t *testing.T) {
+	plan := Plan{
+		Budget:    Duration(20 * time.Minute),
+		WorkRatio: 3,
+		RestRatio: 1,
+		Stations:  6,
+		WarmUp:    Duration(2 * time.Minute),
+		CoolDown:  Duration(2 * time.Minute),
+	}
+	result, err := plan.Generate()
+	require.NoError(t, err)
+	steps := result.Program.Flatten()
+	assert.Equal(t, 20*time.Minute, total(steps))
+	assert.Len(t, steps, 13)
+	assert.Equal(t, "Warm up", steps[0].Name)
+	assert.Equal(t, "Station 1", steps[1].Name)
+	assert.Equal(t, "Station 6", steps[11].Name)
+	// 16m for 6 work and 5 rest parts of 3 and 1: 41.7s a part
+	assert.Equal(t, Duration(125*time.Second), result.Work)
+	assert.Equal(t, Duration(41*time.Second), result.Rest)
+	assert.Len(t, result.Adjustments, 3)
+	assert.Equal(t, Duration(2*time.Minute+5*time.Second), steps[12].Duration)
+	assert.NoError(t, (&Preset{Version: Version, Program: result.Program}).Validate())
+}
+
+func TestPlanWithoutWarmUp(t *testing.T) {
+	plan := Plan{Budget: Duration(10 * time.Minute), WorkRatio: 2, RestRatio: 1, Stations: 4, Rounds: 2}
+	result, err := plan.Generate()
+	require.NoError(t, err)
+	steps := result.Program.Flatten()
+	assert.Equal(t, 10*time.Minute, total(steps))
+	assert.Len(t, steps, 15)
+	assert.Equal(t, "Station 1", steps[8].Name)
+	for _, step := range steps {
+		if step.Phase == Work {
+			assert.InDelta(t, float64(result.Work), float64(step.Duration), float64(time.Second))
+		} else {
+			assert.InDelta(t, float64(result.Rest), float64(step.Duration), float64(time.Second))
+		}
+	}
+
+	exact := Plan{Budget: Duration(7*time.Minute + 30*time.Second), WorkRatio: 1, RestRatio: 1, Stations: 7, CoolDown: Duration(time.Minute)}
+	result, err = exact.Generate()
+	require.NoError(t, err)
+	assert.Equal(t, 7*time.Minute+30*time.Second, total(result.Program.Flatten()))
+	assert.Empty(t, result.Adjustments)
+}
+
+func TestPlanErrors(t *testing.T) {
+	for _, plan := range []Plan{
+		{Budget: Duration(10 * time.Second), WorkRatio: 3, RestRatio: 1, Stations: 6},
+		{Budget: Duration(time.Minute), WorkRatio: 3, RestRatio: 1},
+		{Budget: Duration(time.Minute), WorkRatio: 3, RestRatio: 1, Stations: 2, WarmUp: Duration(time.Minute)},
+		{Budget: Duration(time.Minute), RestRatio: 1, Stations: 2},
+	} {
+		_, err := plan.Generate()
+		assert.Error(t, err, "%+v", plan)
+	}
+
+	work, rest, err := ParseRatio("3:1")
+	require.NoError(t, err)
+	assert.Equal(t, []float64{3, 1}, []float64{work, rest})
+	for _, s := range []string{"3", "a:1", "0:1", "3:-1"} {
+		_, _, err = ParseRatio(s)
+		assert.Error(t, err, s)
+	}
+}
@@ -0,0 +1,275 @@
+package preset
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Version is the version of the preset schema written by this package.
+// Documents written with older versions are upgraded when loaded, see
+// PresetSchema.
+const Version = 2
+
+// Phase is whether a segment is work or rest.
+type Phase string
+
+const (
+	Work Phase = "work"
+	Rest Phase = "rest"
+)
+
+// Preset is a timer program with the sounds, cues and music used while it
+// runs.
+type Preset struct {
+	Version int     `json:"version" yaml:"version"`
+	Name    string  `json:"name,omitempty" yaml:"name,omitempty"`
+	Program Program `json:"program" yaml:"program"`
+	Sounds  Sounds  `json:"sounds,omitempty" yaml:"sounds,omitempty"`
+	Cues    Cues    `json:"cues,omitempty" yaml:"cues,omitempty"`
+	Music   Music   `json:"music,omitempty" yaml:"music,omitempty"`
+}
+
+// Program is the sequence of segments a timer runs. It is either Intervals
+// work intervals separated by rests, a list of Segments, or a Ladder.
+type Program struct {
+	Intervals       int       `json:"intervals,omitempty" yaml:"intervals,omitempty"`
+	Work            Duration  `json:"work,omitempty" yaml:"work,omitempty"`
+	Rest            Duration  `json:"rest,omitempty" yaml:"rest,omitempty"`
+	RestBeforeStart bool      `json:"restBeforeStart,omitempty" yaml:"restBeforeStart,omitempty"`
+	Segments        []Segment `json:"segments,omitempty" yaml:"segments,omitempty"`
+	Ladder          *Ladder   `json:"ladder,omitempty" yaml:"ladder,omitempty"`
+	Cap             Duration  `json:"cap,omitempty" yaml:"cap,omitempty"` // Overall time limit that ends the program early, 0 for none
+}
+
+// Segment is a single timed step of a program, or, if it has Segments, a
+// group of steps.
+type Segment struct {
+	Name        string     `json:"name,omitempty" yaml:"name,omitempty"`
+	Phase       Phase      `json:"phase,omitempty" yaml:"phase,omitempty"`
+	Duration    Duration   `json:"duration,omitempty" yaml:"duration,omitempty"`
+	MaxDuration Duration   `json:"maxDuration,omitempty" yaml:"maxDuration,omitempty"` // Randomizes the length between Duration and MaxDuration when set
+	Repeat      int        `json:"repeat,omitempty" yaml:"repeat,omitempty"`           // Number of times the segment runs, defaults to 1
+	Segments    []Segment  `json:"segments,omitempty" yaml:"segments,omitempty"`
+	Shuffle     bool       `json:"shuffle,omitempty" yaml:"shuffle,omitempty"`     // Run the group's segments in a random order each time it repeats
+	Pick        int        `json:"pick,omitempty" yaml:"pick,omitempty"`           // Number of the group's segments chosen each time it repeats when shuffled, defaults to all
+	Metronome   *Metronome `json:"metronome,omitempty" yaml:"metronome,omitempty"` // Tempo of the step in place of the metronome cue for its phase, silent if StartBPM is zero
+}
+
+// Random returns whether the segment or any segment in it has a randomized
+// length or order.
+func (s Segment) Random() bool {
+	if s.MaxDuration != 0 || s.Shuffle {
+		return true
+	}
+	for _, child := range s.Segments {
+		if child.Random() {
+			return true
+		}
+	}
+	return false
+}
+
+// Sounds are the names of the sounds played for each cue. Empty names leave
+// the current sound unchanged.
+type Sounds struct {
+	Pack        string `json:"pack,omitempty" yaml:"pack,omitempty"` // Sound pack applied before the other sounds
+	IntervalEnd string `json:"intervalEnd,omitempty" yaml:"intervalEnd,omitempty"`
+	Finish      string `json:"finish,omitempty" yaml:"finish,omitempty"`
+	WorkStart   string `json:"workStart,omitempty" yaml:"workStart,omitempty"`
+	RestStart   string `json:"restStart,omitempty" yaml:"restStart,omitempty"`
+	Countdown   string `json:"countdown,omitempty" yaml:"countdown,omitempty"`
+	Beat        string `json:"beat,omitempty" yaml:"beat,omitempty"`
+}
+
+// Cues configure the metronome, spoken announcements and alarm.
+type Cues struct {
+	WorkMetronome     Metronome `json:"workMetronome,omitempty" yaml:"workMetronome,omitempty"`
+	RestMetronome     Metronome `json:"restMetronome,omitempty" yaml:"restMetronome,omitempty"`
+	CountdownFrom     int       `json:"countdownFrom,omitempty" yaml:"countdownFrom,omitempty"`
+	AnnounceHalfway   bool      `json:"announceHalfway,omitempty" yaml:"announceHalfway,omitempty"`
+	AnnounceIntervals bool      `json:"announceIntervals,omitempty" yaml:"announceIntervals,omitempty"`
+	Alarm             Alarm     `json:"alarm,omitempty" yaml:"alarm,omitempty"`
+}
+
+// Alarm loops the finish sound until it is dismissed.
+type Alarm struct {
+	Enabled     bool     `json:"enabled,omitempty" yaml:"enabled,omitempty"`
+	Snooze      Duration `json:"snooze,omitempty" yaml:"snooze,omitempty"`           // Defaults to 5m
+	MaxDuration Duration `json:"maxDuration,omitempty" yaml:"maxDuration,omitempty"` // Defaults to 5m
+}
+
+// Metronome is a tempo ramping from StartBPM to EndBPM over each interval.
+// A zero StartBPM turns the metronome off and a zero EndBPM keeps the tempo
+// constant.
+type Metronome struct {
+	StartBPM float64 `json:"startBPM,omitempty" yaml:"startBPM,omitempty"`
+	EndBPM   float64 `json:"endBPM,omitempty" yaml:"endBPM,omitempty"`
+}
+
+// Music is the background music played while the timer runs.
+type Music struct {
+	WorkDir string  `json:"workDir,omitempty" yaml:"workDir,omitempty"`
+	RestDir string  `json:"restDir,omitempty" yaml:"restDir,omitempty"`
+	Shuffle bool    `json:"shuffle,omitempty" yaml:"shuffle,omitempty"`
+	Volume  float64 `json:"volume,omitempty" yaml:"volume,omitempty"` // Between 0 and 1, defaults to 1
+}
+
+// Format is an encoding of a preset document.
+type Format int
+
+const (
+	JSON Format = iota
+	YAML
+)
+
+// FormatOf returns the format of the preset file at path from its extension.
+func FormatOf(path string) (Format, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return JSON, nil
+	case ".yaml", ".yml":
+		return YAML, nil
+	}
+	return 0, fmt.Errorf("%s is not a .json, .yaml or .yml file", path)
+}
+
+// New returns an empty preset at the current version.
+func New(name string) *Preset {
+	return &Preset{Version: Version, Name: name}
+}
+
+// Load reads a preset document in format from r, upgrades it to the current
+// version and validates it. Returns a ValidationErrors if the preset is
+// invalid.
+func Load(r io.Reader, format Format) (*Preset, error) {
+	p, _, err := LoadReport(r, format)
+	return p, err
+}
+
+// LoadFile reads and validates the preset file at path. The format is
+// determined by the file's extension.
+func LoadFile(path string) (*Preset, error) {
+	format, err := FormatOf(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Load(f, format)
+}
+
+// Save validates the preset and writes it to w in format.
+func (p *Preset) Save(w io.Writer, format Format) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	switch format {
+	case JSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "\t")
+		return encoder.Encode(p)
+	case YAML:
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(p); err != nil {
+			return err
+		}
+		return encoder.Close()
+	}
+	return fmt.Errorf("unknown preset format %d", format)
+}
+
+// SaveFile validates the preset and writes it to the file at path. The
+// format is determined by the file's extension.
+func (p *Preset) SaveFile(path string) error {
+	format, err := FormatOf(path)
+	if err != nil {
+		return err
+	}
+	buf := &bytes.Buffer{}
+	if err = p.Save(buf, format); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// Random returns whether the program has segments with a randomized length
+// or order.
+func (p Program) Random() bool {
+	for _, s := range p.Segments {
+		if s.Random() {
+			return true
+		}
+	}
+	return false
+}
+
+// Flatten returns the timed steps of the program in the order they run, with
+// groups and repeats expanded. Shuffled groups are in the order chosen by
+// seed 0, see Steps.
+func (p Program) Flatten() []Segment {
+	return p.Steps(0)
+}
+
+// Steps returns the timed steps of the program in the order they run, with
+// groups and repeats expanded and shuffled groups ordered by a random source
+// seeded with seed. Steps with a randomized length keep their Duration and
+// MaxDuration, so the length is chosen by the timer when it runs.
+func (p Program) Steps(seed int64) []Segment {
+	if p.Ladder != nil {
+		return flatten(p.Ladder.Segments(), nil)
+	}
+	if len(p.Segments) == 0 {
+		steps := []Segment{}
+		if p.RestBeforeStart && p.Rest > 0 {
+			steps = append(steps, Segment{Phase: Rest, Duration: p.Rest})
+		}
+		for i := 0; i < p.Intervals; i++ {
+			if i > 0 && p.Rest > 0 {
+				steps = append(steps, Segment{Phase: Rest, Duration: p.Rest})
+			}
+			steps = append(steps, Segment{Phase: Work, Duration: p.Work})
+		}
+		return steps
+	}
+	return flatten(p.Segments, rand.New(rand.NewSource(seed)))
+}
+
+func flatten(segments []Segment, rng *rand.Rand) []Segment {
+	steps := []Segment{}
+	for _, s := range segments {
+		repeat := s.Repeat
+		if repeat == 0 {
+			repeat = 1
+		}
+		for i := 0; i < repeat; i++ {
+			if len(s.Segments) == 0 {
+				steps = append(steps, Segment{Name: s.Name, Phase: s.Phase, Duration: s.Duration, MaxDuration: s.MaxDuration, Metronome: s.Metronome})
+				continue
+			}
+			children := s.Segments
+			if s.Shuffle {
+				children = make([]Segment, len(s.Segments))
+				for j, k := range rng.Perm(len(s.Segments)) {
+					children[j] = s.Segments[k]
+				}
+				if s.Pick > 0 && s.Pick < len(children) {
+					children = children[:s.Pick]
+				}
+			}
+			steps = append(steps, flatten(children, rng)...)
+		}
+	}
+	return steps
+}
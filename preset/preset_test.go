@@ -0,0 +1,164 @@
+package preset
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sprints() *Preset {
+	return &Preset{
+		Version: Version,
+		Name:    "Sprints",
+		Program: Program{Segments: []Segment{
+			{Name: "Warm up", Phase: Rest, Duration: Duration(5 * time.Minute)},
+			{Repeat: 3, Segments: []Segment{
+				{Name: "Sprint", Phase: Work, Duration: Duration(30 * time.Second)},
+				{Phase: Rest, Duration: Duration(90 * time.Second)},
+			}},
+		}},
+		Sounds: Sounds{IntervalEnd: "Ding", Finish: "Triple Beep"},
+		Cues: Cues{
+			WorkMetronome: Metronome{StartBPM: 120, EndBPM: 160},
+			CountdownFrom: 3,
+			Alarm:         Alarm{Enabled: true, Snooze: Duration(time.Minute)},
+		},
+		Music: Music{WorkDir: "/music/fast", Shuffle: true, Volume: 0.5},
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, format := range []Format{JSON, YAML} {
+		buf := &bytes.Buffer{}
+		require.NoError(t, sprints().Save(buf, format))
+		loaded, err := Load(buf, format)
+		require.NoError(t, err)
+		assert.Equal(t, sprints(), loaded)
+	}
+}
+
+func TestSaveFile(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"sprints.json", "sprints.yaml", "sprints.yml"} {
+		path := filepath.Join(dir, name)
+		require.NoError(t, sprints().SaveFile(path))
+		loaded, err := LoadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, sprints(), loaded)
+	}
+	assert.Error(t, sprints().SaveFile(filepath.Join(dir, "sprints.txt")))
+}
+
+func TestLoadDurations(t *testing.T) {
+	doc := `{"version": 1, "program": {"intervals": 2, "work": 45, "rest": "1m15s"}}`
+	p, err := Load(strings.NewReader(doc), JSON)
+	require.NoError(t, err)
+	assert.Equal(t, Duration(45*time.Second), p.Program.Work)
+	assert.Equal(t, Duration(75*time.Second), p.Program.Rest)
+
+	doc = "version: 1\nprogram:\n  intervals: 2\n  work: 45\n  rest: 1m15s\n"
+	p, err = Load(strings.NewReader(doc), YAML)
+	require.NoError(t, err)
+	assert.Equal(t, Duration(45*time.Second), p.Program.Work)
+	assert.Equal(t, Duration(75*time.Second), p.Program.Rest)
+}
+
+func TestValidationErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		doc    string
+		fields []string
+	}{
+		{"missing version", `{"program": {"intervals": 1, "work": "10s"}}`, []string{"version"}},
+		{"newer version", `{"version": 99, "program": {"intervals": 1, "work": "10s"}}`, []string{"version"}},
+		{"empty program", `{"version": 1, "program": {}}`, []string{"program.intervals", "program.work"}},
+		{"both kinds of program", `{"version": 1, "program": {"intervals": 2, "segments": [{"phase": "work", "duration": "1s"}]}}`, []string{"program"}},
+		{
+			"nested segment",
+			`{"version": 1, "program": {"segments": [{"phase": "rest", "duration": "1m"}, {"repeat": 2, "segments": [{"phase": "work", "duration": "1.5s"}, {"phase": "nap", "duration": "1s"}]}]}}`,
+			[]string{"program.segments[1].segments[0].duration", "program.segments[1].segments[1].phase"},
+		},
+		{"metronome", `{"version": 1, "program": {"intervals": 1, "work": "10s"}, "cues": {"restMetronome": {"endBPM": 100}}}`, []string{"cues.restMetronome.startBPM"}},
+		{
+			"segment metronome",
+			`{"version": 1, "program": {"segments": [{"phase": "work", "duration": "1m", "metronome": {"startBPM": -20}}, {"metronome": {"startBPM": 20}, "segments": [{"phase": "work", "duration": "1s"}]}]}}`,
+			[]string{"program.segments[0].metronome.startBPM", "program.segments[1].metronome"},
+		},
+		{"music volume", `{"version": 1, "program": {"intervals": 1, "work": "10s"}, "music": {"volume": 2}}`, []string{"music.volume"}},
+		{
+			"random segments",
+			`{"version": 2, "program": {"segments": [{"phase": "rest", "duration": "30s", "maxDuration": "10s"}, {"phase": "work", "duration": "1s", "shuffle": true}, {"pick": 3, "segments": [{"phase": "work", "duration": "1s"}]}]}}`,
+			[]string{"program.segments[0].maxDuration", "program.segments[1]", "program.segments[2].pick"},
+		},
+		{"time cap", `{"version": 2, "program": {"intervals": 1, "work": "10s", "cap": "1.5s"}}`, []string{"program.cap"}},
+		{"wrong type", `{"version": 1, "program": {"intervals": "two", "work": "10s"}}`, []string{"program.intervals"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(strings.NewReader(tt.doc), JSON)
+			var errs ValidationErrors
+			if assert.True(t, errors.As(err, &errs), "got %v", err) {
+				fields := []string{}
+				for _, e := range errs {
+					fields = append(fields, e.Field)
+				}
+				assert.Equal(t, tt.fields, fields)
+			}
+		})
+	}
+}
+
+func TestLoadUnknownField(t *testing.T) {
+	_, err := Load(strings.NewReader(`{"version": 1, "program": {"intervals": 1, "work": "10s"}, "colour": "red"}`), JSON)
+	assert.ErrorContains(t, err, "colour")
+	_, err = Load(strings.NewReader("version: 1\nprogram: {intervals: 1, work: 10s}\ncolour: red\n"), YAML)
+	assert.ErrorContains(t, err, "colour")
+}
+
+func TestFlatten(t *testing.T) {
+	work := Segment{Phase: Work, Duration: Duration(20 * time.Second)}
+	rest := Segment{Phase: Rest, Duration: Duration(10 * time.Second)}
+	assert.Equal(t, []Segment{rest, work, rest, work}, Program{Intervals: 2, Work: work.Duration, Rest: rest.Duration, RestBeforeStart: true}.Flatten())
+
+	steps := sprints().Program.Flatten()
+	if assert.Len(t, steps, 7) {
+		assert.Equal(t, "Warm up", steps[0].Name)
+		for i := 1; i < 7; i += 2 {
+			assert.Equal(t, "Sprint", steps[i].Name)
+			assert.Equal(t, Rest, steps[i+1].Phase)
+		}
+	}
+}
+
+func TestSteps(t *testing.T) {
+	p, err := ParseProgram(`4x shuffle 2(30s work "A", 30s work "B", 30s work "C"); 10s..30s rest`)
+	require.NoError(t, err)
+	assert.True(t, p.Random())
+	names := func(seed int64) []string {
+		names := []string{}
+		for _, step := range p.Steps(seed) {
+			names = append(names, step.Name)
+		}
+		return names
+	}
+
+	first := names(7)
+	assert.Len(t, first, 9)
+	assert.Equal(t, first, names(7))
+	different := false
+	for seed := int64(8); seed < 20 && !different; seed++ {
+		different = !assert.ObjectsAreEqual(first, names(seed))
+	}
+	assert.True(t, different, "every seed shuffled the same way")
+
+	last := p.Steps(7)[8]
+	assert.Equal(t, Duration(10*time.Second), last.Duration)
+	assert.Equal(t, Duration(30*time.Second), last.MaxDuration)
+	assert.False(t, sprints().Program.Random())
+}
@@ -0,0 +1,155 @@
+package preset
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ValidationError is an invalid field in a preset.
+type ValidationError struct {
+	Field   string // Path of the field in the document, such as "program.segments[2].duration"
+	Line    int    // Line of the field in a YAML document, 0 if unknown
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("line %d: %s: %s", e.Line, e.Field, e.Message)
+	}
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// ValidationErrors are all of the invalid fields in a preset.
+type ValidationErrors []ValidationError
+
+func (errs ValidationErrors) Error() string {
+	messages := make([]string, len(errs))
+	for i, err := range errs {
+		messages[i] = err.Error()
+	}
+	return "invalid preset: " + strings.Join(messages, "; ")
+}
+
+// Validate returns a ValidationErrors listing every invalid field in the
+// preset, or nil if it is valid.
+func (p *Preset) Validate() error {
+	v := &validator{}
+	switch {
+	case p.Version == 0:
+		v.add("version", "is required")
+	case p.Version > Version:
+		v.add("version", "%d is newer than the supported version %d", p.Version, Version)
+	case p.Version < 0:
+		v.add("version", "must be positive")
+	}
+	v.program("program", p.Program)
+	v.metronome("cues.workMetronome", p.Cues.WorkMetronome)
+	v.metronome("cues.restMetronome", p.Cues.RestMetronome)
+	v.duration("cues.alarm.snooze", p.Cues.Alarm.Snooze, false)
+	v.duration("cues.alarm.maxDuration", p.Cues.Alarm.MaxDuration, false)
+	if p.Cues.CountdownFrom < 0 {
+		v.add("cues.countdownFrom", "must not be negative")
+	}
+	if p.Music.Volume < 0 || p.Music.Volume > 1 {
+		v.add("music.volume", "must be between 0 and 1")
+	}
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return v.errs
+}
+
+type validator struct {
+	errs ValidationErrors
+}
+
+func (v *validator) add(field, format string, args ...interface{}) {
+	v.errs = append(v.errs, ValidationError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) program(field string, p Program) {
+	intervals := p.Intervals != 0 || p.Work != 0 || p.Rest != 0 || p.RestBeforeStart
+	v.duration(field+".cap", p.Cap, false)
+	if p.Ladder != nil {
+		if intervals || len(p.Segments) > 0 {
+			v.add(field, "must have only one of intervals, segments or a ladder")
+		}
+		v.ladder(field+".ladder", *p.Ladder)
+		return
+	}
+	if len(p.Segments) > 0 {
+		if intervals {
+			v.add(field, "must have either segments or intervals, not both")
+		}
+		v.segments(field+".segments", p.Segments)
+		return
+	}
+	if p.Intervals <= 0 {
+		v.add(field+".intervals", "must be positive")
+	}
+	v.duration(field+".work", p.Work, true)
+	v.duration(field+".rest", p.Rest, false)
+}
+
+func (v *validator) segments(field string, segments []Segment) {
+	for i, s := range segments {
+		f := fmt.Sprintf("%s[%d]", field, i)
+		if s.Repeat < 0 {
+			v.add(f+".repeat", "must not be negative")
+		}
+		if len(s.Segments) > 0 {
+			if s.Phase != "" || s.Duration != 0 || s.MaxDuration != 0 {
+				v.add(f, "must have either segments or a phase and duration, not both")
+			}
+			if s.Metronome != nil {
+				v.add(f+".metronome", "only steps can have a metronome")
+			}
+			if s.Pick < 0 || s.Pick > len(s.Segments) {
+				v.add(f+".pick", "must be between 0 and the number of segments, %d", len(s.Segments))
+			} else if s.Pick > 0 && !s.Shuffle {
+				v.add(f+".pick", "requires shuffle")
+			}
+			v.segments(f+".segments", s.Segments)
+			continue
+		}
+		if s.Phase != Work && s.Phase != Rest {
+			v.add(f+".phase", "must be %q or %q", Work, Rest)
+		}
+		if s.Shuffle || s.Pick != 0 {
+			v.add(f, "only groups of segments can be shuffled")
+		}
+		v.duration(f+".duration", s.Duration, true)
+		if s.MaxDuration != 0 {
+			v.duration(f+".maxDuration", s.MaxDuration, true)
+			if s.MaxDuration <= s.Duration {
+				v.add(f+".maxDuration", "must be longer than duration")
+			}
+		}
+		if s.Metronome != nil {
+			v.metronome(f+".metronome", *s.Metronome)
+		}
+	}
+}
+
+func (v *validator) duration(field string, d Duration, required bool) {
+	switch {
+	case d < 0:
+		v.add(field, "must not be negative")
+	case required && time.Duration(d) < time.Second:
+		v.add(field, "must be at least 1s")
+	case time.Duration(d)%time.Second != 0:
+		v.add(field, "must be a whole number of seconds")
+	}
+}
+
+func (v *validator) metronome(field string, m Metronome) {
+	switch {
+	case m.StartBPM < 0:
+		v.add(field+".startBPM", "must not be negative")
+	case m.EndBPM < 0:
+		v.add(field+".endBPM", "must not be negative")
+	case m.StartBPM == 0 && m.EndBPM != 0:
+		v.add(field+".startBPM", "is required when endBPM is set")
+	}
+}
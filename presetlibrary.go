@@ -0,0 +1,178 @@
+package timer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"github.com/gabriel-ross/timer-go/preset"
+)
+
+const (
+	PREF_PRESETS     = "presets"
+	PREF_LAST_PRESET = "preset.last"
+)
+
+// ErrPresetExists is returned when a preset can't be added to the preset
+// library because a preset of the same name already exists.
+var ErrPresetExists = errors.New("a preset with that name already exists")
+
+// presetLibrary is the presets saved by the user, stored in the app's
+// preferences as a JSON object of preset documents keyed by name.
+type presetLibrary struct {
+	prefs fyne.Preferences
+}
+
+func (l presetLibrary) documents() map[string]json.RawMessage {
+	docs := map[string]json.RawMessage{}
+	if saved := l.prefs.String(PREF_PRESETS); saved != "" {
+		if err := json.Unmarshal([]byte(saved), &docs); err != nil {
+			log.Printf("error reading saved presets: %v\n", err)
+		}
+	}
+	return docs
+}
+
+func (l presetLibrary) setDocuments(docs map[string]json.RawMessage) error {
+	data, err := json.Marshal(docs)
+	if err != nil {
+		return fmt.Errorf("saving presets: %w", err)
+	}
+	l.prefs.SetString(PREF_PRESETS, string(data))
+	return nil
+}
+
+// names returns the names of the saved presets in order.
+func (l presetLibrary) names() []string {
+	docs := l.documents()
+	names := make([]string, 0, len(docs))
+	for name := range docs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// get returns the saved preset called name.
+func (l presetLibrary) get(name string) (*preset.Preset, error) {
+	doc, exists := l.documents()[name]
+	if !exists {
+		return nil, fmt.Errorf("no preset called %q", name)
+	}
+	p, err := preset.Load(bytes.NewReader(doc), preset.JSON)
+	if err != nil {
+		return nil, fmt.Errorf("loading preset %q: %w", name, err)
+	}
+	return p, nil
+}
+
+// save saves p under its name, replacing any preset of the same name.
+func (l presetLibrary) save(p *preset.Preset) error {
+	if strings.TrimSpace(p.Name) == "" {
+		return errors.New("preset name must not be empty")
+	}
+	buf := &bytes.Buffer{}
+	if err := p.Save(buf, preset.JSON); err != nil {
+		return fmt.Errorf("saving preset %q: %w", p.Name, err)
+	}
+	docs := l.documents()
+	docs[p.Name] = buf.Bytes()
+	return l.setDocuments(docs)
+}
+
+// rename renames the preset called from to to.
+func (l presetLibrary) rename(from, to string) error {
+	if from == to {
+		return nil
+	}
+	p, err := l.get(from)
+	if err != nil {
+		return err
+	}
+	if _, exists := l.documents()[to]; exists {
+		return fmt.Errorf("renaming %q to %q: %w", from, to, ErrPresetExists)
+	}
+	p.Name = to
+	if err = l.save(p); err != nil {
+		return err
+	}
+	return l.remove(from)
+}
+
+// duplicate saves a copy of the preset called name and returns the name of
+// the copy.
+func (l presetLibrary) duplicate(name string) (string, error) {
+	p, err := l.get(name)
+	if err != nil {
+		return "", err
+	}
+	p.Name = l.unusedName(name + " copy")
+	return p.Name, l.save(p)
+}
+
+// add saves p under its name, or if that is taken under its name followed by
+// a number, and returns the name it was saved as.
+func (l presetLibrary) add(p *preset.Preset) (string, error) {
+	p.Name = l.unusedName(p.Name)
+	return p.Name, l.save(p)
+}
+
+// unusedName returns name, or if a preset called name exists, name followed
+// by the lowest number that makes it unique.
+func (l presetLibrary) unusedName(name string) string {
+	docs := l.documents()
+	unused := name
+	for i := 2; ; i++ {
+		if _, exists := docs[unused]; !exists {
+			return unused
+		}
+		unused = fmt.Sprintf("%s %d", name, i)
+	}
+}
+
+// remove deletes the preset called name.
+func (l presetLibrary) remove(name string) error {
+	docs := l.documents()
+	if _, exists := docs[name]; !exists {
+		return fmt.Errorf("no preset called %q", name)
+	}
+	delete(docs, name)
+	return l.setDocuments(docs)
+}
+
+// upgrade rewrites the saved presets written with an older version of the
+// preset schema at the current version. Returns the reports of the upgraded
+// presets by name and errors for presets that can't be read.
+func (l presetLibrary) upgrade() (map[string]*preset.MigrationReport, []error) {
+	docs := l.documents()
+	reports := map[string]*preset.MigrationReport{}
+	var errs []error
+	for name, doc := range docs {
+		p, report, err := preset.LoadReport(bytes.NewReader(doc), preset.JSON)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("loading preset %q: %w", name, err))
+			continue
+		}
+		if !report.Changed() {
+			continue
+		}
+		buf := &bytes.Buffer{}
+		if err = p.Save(buf, preset.JSON); err != nil {
+			errs = append(errs, fmt.Errorf("upgrading preset %q: %w", name, err))
+			continue
+		}
+		docs[name] = buf.Bytes()
+		reports[name] = report
+	}
+	if len(reports) > 0 {
+		if err := l.setDocuments(docs); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return reports, errs
+}
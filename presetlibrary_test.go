@@ -0,0 +1,94 @@
+package timer
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"fyne.io/fyne/v2/test"
+	"github.com/gabriel-ross/timer-go/preset"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPresetLibrary(t *testing.T) {
+	library := presetLibrary{prefs: test.NewApp().Preferences()}
+	assert.Empty(t, library.names())
+
+	tabata := preset.New("Tabata")
+	tabata.Program = preset.Program{Intervals: 8, Work: preset.Duration(20 * time.Second), Rest: preset.Duration(10 * time.Second)}
+	require.NoError(t, library.save(tabata))
+	emom := preset.New("EMOM")
+	emom.Program = preset.Program{Intervals: 10, Work: preset.Duration(time.Minute)}
+	require.NoError(t, library.save(emom))
+	assert.Error(t, library.save(preset.New("Invalid")))
+	assert.Error(t, library.save(preset.New(" ")))
+	assert.Equal(t, []string{"EMOM", "Tabata"}, library.names())
+
+	// presets are read back from the preferences
+	library = presetLibrary{prefs: library.prefs}
+	loaded, err := library.get("Tabata")
+	require.NoError(t, err)
+	assert.Equal(t, tabata, loaded)
+	_, err = library.get("Sprints")
+	assert.Error(t, err)
+
+	tabata.Cues.CountdownFrom = 3
+	require.NoError(t, library.save(tabata))
+	loaded, err = library.get("Tabata")
+	require.NoError(t, err)
+	assert.Equal(t, 3, loaded.Cues.CountdownFrom)
+
+	copied, err := library.duplicate("Tabata")
+	require.NoError(t, err)
+	assert.Equal(t, "Tabata copy", copied)
+	copied, err = library.duplicate("Tabata")
+	require.NoError(t, err)
+	assert.Equal(t, "Tabata copy 2", copied)
+
+	assert.True(t, errors.Is(library.rename("Tabata copy", "EMOM"), ErrPresetExists))
+	require.NoError(t, library.rename("Tabata copy", "Tabata short"))
+	loaded, err = library.get("Tabata short")
+	require.NoError(t, err)
+	assert.Equal(t, "Tabata short", loaded.Name)
+
+	require.NoError(t, library.remove("Tabata copy 2"))
+	assert.Error(t, library.remove("Tabata copy 2"))
+	assert.Equal(t, []string{"EMOM", "Tabata", "Tabata short"}, library.names())
+}
+
+func TestPresetLibraryAdd(t *testing.T) {
+	library := presetLibrary{prefs: test.NewApp().Preferences()}
+	for _, expected := range []string{"Tabata", "Tabata 2", "Tabata 3"} {
+		tabata := preset.New("Tabata")
+		tabata.Program = preset.Program{Intervals: 8, Work: preset.Duration(20 * time.Second), Rest: preset.Duration(10 * time.Second)}
+		name, err := library.add(tabata)
+		require.NoError(t, err)
+		assert.Equal(t, expected, name)
+	}
+	assert.Equal(t, []string{"Tabata", "Tabata 2", "Tabata 3"}, library.names())
+}
+
+func TestPresetLibraryUpgrade(t *testing.T) {
+	library := presetLibrary{prefs: test.NewApp().Preferences()}
+	library.prefs.SetString(PREF_PRESETS, `{
+		"Old": {"version": 1, "name": "Old", "program": {"intervals": 2, "work": "20s"}, "cues": {"alarm": true}},
+		"Broken": {"version": 1, "program": {}}
+	}`)
+	current := preset.New("Current")
+	current.Program = preset.Program{Intervals: 1, Work: preset.Duration(time.Minute)}
+	require.NoError(t, library.save(current))
+
+	reports, errs := library.upgrade()
+	assert.Len(t, errs, 1)
+	if assert.Contains(t, reports, "Old") {
+		assert.Equal(t, 1, reports["Old"].From)
+	}
+	assert.NotContains(t, reports, "Current")
+
+	reports, _ = library.upgrade()
+	assert.Empty(t, reports)
+	old, err := library.get("Old")
+	require.NoError(t, err)
+	assert.True(t, old.Cues.Alarm.Enabled)
+}
@@ -0,0 +1,327 @@
+package timer
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gabriel-ross/timer-go/internal"
+	"github.com/gabriel-ross/timer-go/preset"
+)
+
+// Preset returns the application's current program, sounds, cues and music
+// as a preset called name.
+func (a *application) Preset(name string) *preset.Preset {
+	p := preset.New(name)
+	cnf := a.timerConfig
+	p.Program = a.Program()
+	p.Sounds = preset.Sounds{
+		Pack:        a.soundPack,
+		IntervalEnd: a.CueSound(IntervalEndRole),
+		Finish:      a.CueSound(FinishRole),
+		WorkStart:   a.CueSound(WorkStartRole),
+		RestStart:   a.CueSound(RestStartRole),
+		Countdown:   a.CueSound(CountdownRole),
+		Beat:        a.CueSound(BeatRole),
+	}
+	p.Cues = preset.Cues{
+		WorkMetronome:     preset.Metronome{StartBPM: cnf.WorkMetronome.StartBPM, EndBPM: cnf.WorkMetronome.EndBPM},
+		RestMetronome:     preset.Metronome{StartBPM: cnf.RestMetronome.StartBPM, EndBPM: cnf.RestMetronome.EndBPM},
+		CountdownFrom:     cnf.CountdownFrom,
+		AnnounceHalfway:   cnf.AnnounceHalfway,
+		AnnounceIntervals: cnf.AnnounceIntervals,
+	}
+	alarm := a.AlarmConfig()
+	p.Cues.Alarm = preset.Alarm{
+		Enabled:     alarm.Enabled,
+		Snooze:      preset.Duration(alarm.Snooze),
+		MaxDuration: preset.Duration(alarm.MaxDuration),
+	}
+	music := a.MusicConfig()
+	p.Music = preset.Music{
+		WorkDir: music.WorkDir,
+		RestDir: music.RestDir,
+		Shuffle: music.Shuffle,
+		Volume:  music.Volume,
+	}
+	return p
+}
+
+// ApplyPreset validates p and replaces the application's program, sounds,
+// cues and music with those in p. Sounds that aren't registered and music
+// that can't be loaded are reported in the returned error after the rest of
+// the preset has been applied.
+func (a *application) ApplyPreset(p *preset.Preset) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+
+	a.setProgram(p.Program)
+	cnf := a.timerConfig
+	cnf.WorkMetronome = internal.Metronome{StartBPM: p.Cues.WorkMetronome.StartBPM, EndBPM: p.Cues.WorkMetronome.EndBPM}
+	cnf.RestMetronome = internal.Metronome{StartBPM: p.Cues.RestMetronome.StartBPM, EndBPM: p.Cues.RestMetronome.EndBPM}
+	cnf.CountdownFrom = p.Cues.CountdownFrom
+	cnf.AnnounceHalfway = p.Cues.AnnounceHalfway
+	cnf.AnnounceIntervals = p.Cues.AnnounceIntervals
+
+	a.SetAlarmConfig(AlarmConfig{
+		Enabled:     p.Cues.Alarm.Enabled,
+		Snooze:      time.Duration(p.Cues.Alarm.Snooze),
+		MaxDuration: time.Duration(p.Cues.Alarm.MaxDuration),
+	})
+
+	var errs []error
+	if p.Sounds.Pack != "" {
+		if err := a.UseSoundPack(p.Sounds.Pack); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for role, name := range map[CueRole]string{
+		IntervalEndRole: p.Sounds.IntervalEnd,
+		FinishRole:      p.Sounds.Finish,
+		WorkStartRole:   p.Sounds.WorkStart,
+		RestStartRole:   p.Sounds.RestStart,
+		CountdownRole:   p.Sounds.Countdown,
+		BeatRole:        p.Sounds.Beat,
+	} {
+		if name == "" {
+			continue
+		}
+		if err := a.SetCueSound(role, name); err != nil {
+			errs = append(errs, fmt.Errorf("%s sound: %w", role, err))
+		}
+	}
+
+	music := a.MusicConfig()
+	music.WorkDir = p.Music.WorkDir
+	music.RestDir = p.Music.RestDir
+	music.Shuffle = p.Music.Shuffle
+	music.Volume = p.Music.Volume
+	if err := a.SetMusicConfig(music); err != nil {
+		errs = append(errs, fmt.Errorf("loading music: %w", err))
+	}
+	return errors.Join(errs...)
+}
+
+// Program returns the program the timer runs.
+func (a *application) Program() preset.Program {
+	if a.program != nil {
+		return *a.program
+	}
+	cnf := a.timerConfig
+	return preset.Program{
+		Intervals:       cnf.Intervals,
+		Work:            preset.Duration(time.Duration(cnf.IntervalMinutes)*time.Minute + time.Duration(cnf.IntervalSeconds)*time.Second),
+		Rest:            preset.Duration(time.Duration(cnf.RestMinutes)*time.Minute + time.Duration(cnf.RestSeconds)*time.Second),
+		RestBeforeStart: cnf.RestBeforeStart,
+		Cap:             preset.Duration(a.TimeCap()),
+	}
+}
+
+// SetProgram validates program and makes it the program the timer runs.
+func (a *application) SetProgram(program preset.Program) error {
+	p := preset.New("")
+	p.Program = program
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	a.setProgram(program)
+	return nil
+}
+
+func (a *application) setProgram(program preset.Program) {
+	cnf := a.timerConfig
+	cnf.Segments = nil
+	cnf.Tracks = timeCapTracks(time.Duration(program.Cap))
+	a.program = nil
+	if len(program.Segments) > 0 || program.Ladder != nil {
+		a.program = &program
+		cnf.Segments = programSegments(program, 0)
+		cnf.Intervals = 0
+		cnf.IntervalMinutes, cnf.IntervalSeconds = 0, 0
+		cnf.RestMinutes, cnf.RestSeconds = 0, 0
+		cnf.RestBeforeStart = false
+		return
+	}
+	work, rest := time.Duration(program.Work), time.Duration(program.Rest)
+	cnf.Intervals = program.Intervals
+	cnf.IntervalMinutes, cnf.IntervalSeconds = int64(work/time.Minute), int64(work%time.Minute/time.Second)
+	cnf.RestMinutes, cnf.RestSeconds = int64(rest/time.Minute), int64(rest%time.Minute/time.Second)
+	cnf.RestEnabled = rest > 0
+	cnf.RestBeforeStart = program.RestBeforeStart
+}
+
+// TimeCap returns the overall time limit of the program, 0 if it has none.
+func (a *application) TimeCap() time.Duration {
+	for _, track := range a.timerConfig.Tracks {
+		if track.EndsProgram {
+			return track.Segments[0].Duration
+		}
+	}
+	return 0
+}
+
+// SetTimeCap sets the overall time limit of the program. The program ends
+// when the limit is reached even if it has intervals left. 0 removes the
+// limit.
+func (a *application) SetTimeCap(limit time.Duration) error {
+	if limit < 0 || limit%time.Second != 0 {
+		return errors.New("the time cap must be a whole number of seconds")
+	}
+	if a.program != nil {
+		a.program.Cap = preset.Duration(limit)
+	}
+	a.timerConfig.Tracks = timeCapTracks(limit)
+	return nil
+}
+
+// timeCapTracks returns the tracks that run a time cap of limit alongside
+// the program, none if limit is 0.
+func timeCapTracks(limit time.Duration) []internal.Track {
+	if limit == 0 {
+		return nil
+	}
+	return []internal.Track{{
+		Name:        TIME_CAP_NAME,
+		Segments:    []internal.Segment{{Phase: internal.WorkPhase, Duration: limit}},
+		EndsProgram: true,
+	}}
+}
+
+// Seed returns the seed of the random choices in the current or most recent
+// run of the timer.
+func (a *application) Seed() int64 {
+	return a.seed
+}
+
+// ReplaySeed makes the next run of the timer use seed for its random choices,
+// repeating the shuffled order and randomized lengths of the run that used
+// it.
+func (a *application) ReplaySeed(seed int64) {
+	a.replaySeed = &seed
+}
+
+// ClearProgram replaces a program of segments loaded from a preset with
+// the interval settings.
+func (a *application) ClearProgram() {
+	a.program = nil
+	a.timerConfig.Segments = nil
+}
+
+// programSegments returns the engine segments that run program, with
+// shuffled groups ordered by seed.
+func programSegments(program preset.Program, seed int64) []internal.Segment {
+	steps := program.Steps(seed)
+	segments := make([]internal.Segment, len(steps))
+	for i, step := range steps {
+		segments[i] = internal.Segment{
+			Name:        step.Name,
+			Phase:       internal.WorkPhase,
+			Duration:    time.Duration(step.Duration),
+			MaxDuration: time.Duration(step.MaxDuration),
+		}
+		if m := step.Metronome; m != nil {
+			segments[i].Metronome = &internal.Metronome{StartBPM: m.StartBPM, EndBPM: m.EndBPM}
+		}
+		if step.Phase == preset.Rest {
+			segments[i].Phase = internal.RestPhase
+		}
+	}
+	return segments
+}
+
+// SavedPresets returns the names of the presets in the preset library in
+// order.
+func (a *application) SavedPresets() []string {
+	return a.presets.names()
+}
+
+// LastPreset returns the name of the preset most recently saved or loaded
+// from the preset library, which is loaded when the application starts.
+func (a *application) LastPreset() string {
+	return a.guiDriver.Preferences().String(PREF_LAST_PRESET)
+}
+
+// SavePreset saves the current program, sounds, cues and music in the preset
+// library as name, replacing any preset of the same name.
+func (a *application) SavePreset(name string) error {
+	if err := a.presets.save(a.Preset(name)); err != nil {
+		return err
+	}
+	a.guiDriver.Preferences().SetString(PREF_LAST_PRESET, name)
+	return nil
+}
+
+// LoadPreset applies the preset called name from the preset library. See
+// ApplyPreset.
+func (a *application) LoadPreset(name string) error {
+	p, err := a.presets.get(name)
+	if err != nil {
+		if a.LastPreset() == name {
+			a.guiDriver.Preferences().RemoveValue(PREF_LAST_PRESET)
+		}
+		return err
+	}
+	a.guiDriver.Preferences().SetString(PREF_LAST_PRESET, name)
+	return a.ApplyPreset(p)
+}
+
+// RenamePreset renames a preset in the preset library.
+func (a *application) RenamePreset(from, to string) error {
+	if err := a.presets.rename(from, to); err != nil {
+		return err
+	}
+	if a.LastPreset() == from {
+		a.guiDriver.Preferences().SetString(PREF_LAST_PRESET, to)
+	}
+	a.presets.renameInSession(from, to)
+	a.renameInSchedule(from, to)
+	return nil
+}
+
+// DuplicatePreset saves a copy of a preset in the preset library and returns
+// the name of the copy.
+func (a *application) DuplicatePreset(name string) (string, error) {
+	return a.presets.duplicate(name)
+}
+
+// DeletePreset deletes a preset from the preset library and removes it from
+// the session. A start scheduled to load it is cancelled.
+func (a *application) DeletePreset(name string) error {
+	if err := a.presets.remove(name); err != nil {
+		return err
+	}
+	if a.LastPreset() == name {
+		a.guiDriver.Preferences().RemoveValue(PREF_LAST_PRESET)
+	}
+	a.presets.removeFromSession(name)
+	if start := a.ScheduledStart(); start != nil && start.Preset == name {
+		a.CancelScheduledStart()
+	}
+	return nil
+}
+
+// PresetCode returns a code for sharing the preset called name from the
+// preset library. See preset.Encode.
+func (a *application) PresetCode(name string) (string, error) {
+	p, err := a.presets.get(name)
+	if err != nil {
+		return "", err
+	}
+	return preset.Encode(p)
+}
+
+// ImportPresetCode decodes a shared preset code into the preset library and
+// returns the name it was saved as, which has a number added if a preset of
+// the same name exists.
+func (a *application) ImportPresetCode(code string) (string, error) {
+	p, err := preset.Decode(code)
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(p.Name) == "" {
+		p.Name = "Shared preset"
+	}
+	return a.presets.add(p)
+}
@@ -0,0 +1,247 @@
+package timer
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gabriel-ross/timer-go/internal"
+)
+
+const PREF_SCHEDULE = "schedule"
+
+// ScheduledStart starts the timer at a time of day, once or on a recurring
+// schedule.
+type ScheduledStart struct {
+	Preset   string // Name of the preset in the preset library loaded before starting, empty to start with the current settings
+	Schedule internal.Schedule
+}
+
+// String describes the scheduled start, such as "Sprints at 07:00 on Mon".
+func (s ScheduledStart) String() string {
+	name := s.Preset
+	if name == "" {
+		name = "Current settings"
+	}
+	return fmt.Sprintf("%s at %s", name, s.Schedule)
+}
+
+// schedulePref is a recurring ScheduledStart as stored in the app's
+// preferences.
+type schedulePref struct {
+	Preset string         `json:"preset,omitempty"`
+	At     string         `json:"at"`
+	Days   []time.Weekday `json:"days"`
+}
+
+// scheduledStart returns the recurring scheduled start saved in the
+// preferences, nil if there is none.
+func (l presetLibrary) scheduledStart() *ScheduledStart {
+	saved := l.prefs.String(PREF_SCHEDULE)
+	if saved == "" {
+		return nil
+	}
+	pref := schedulePref{}
+	if err := json.Unmarshal([]byte(saved), &pref); err != nil {
+		log.Printf("error reading schedule: %v\n", err)
+		return nil
+	}
+	at, err := internal.ParseTimeOfDay(pref.At)
+	if err != nil {
+		log.Printf("error reading schedule: %v\n", err)
+		return nil
+	}
+	schedule := internal.Schedule{At: at, Days: pref.Days}
+	if err = schedule.Validate(); err != nil {
+		log.Printf("error reading schedule: %v\n", err)
+		return nil
+	}
+	return &ScheduledStart{Preset: pref.Preset, Schedule: schedule}
+}
+
+// setScheduledStart validates start and saves it if it recurs, so that it
+// carries on when the app is next opened. A nil or single start removes the
+// saved schedule.
+func (l presetLibrary) setScheduledStart(start *ScheduledStart) error {
+	if start != nil {
+		if err := start.Schedule.Validate(); err != nil {
+			return fmt.Errorf("scheduling start: %w", err)
+		}
+	}
+	if start != nil && start.Preset != "" {
+		if _, exists := l.documents()[start.Preset]; !exists {
+			return fmt.Errorf("no preset called %q", start.Preset)
+		}
+	}
+	if start == nil || !start.Schedule.Recurring() {
+		l.prefs.RemoveValue(PREF_SCHEDULE)
+		return nil
+	}
+	data, err := json.Marshal(schedulePref{Preset: start.Preset, At: start.Schedule.At.String(), Days: start.Schedule.Days})
+	if err != nil {
+		return fmt.Errorf("saving schedule: %w", err)
+	}
+	l.prefs.SetString(PREF_SCHEDULE, string(data))
+	return nil
+}
+
+// ScheduledStart returns the start the timer is scheduled for, nil if there
+// is none.
+func (a *application) ScheduledStart() *ScheduledStart {
+	a.scheduleMu.Lock()
+	defer a.scheduleMu.Unlock()
+	if a.scheduled == nil {
+		return nil
+	}
+	start := *a.scheduled
+	return &start
+}
+
+// ScheduleStart schedules the timer to start on the wall clock, replacing
+// any start scheduled before. When a start is due its preset is loaded as if
+// chosen from the preset library and the timer starts as if Start were
+// tapped. A start is skipped if the timer is already running, or if it was
+// missed by more than the schedule's grace, such as while the computer
+// slept. A recurring schedule is saved and carries on when the app is next
+// opened.
+func (a *application) ScheduleStart(start ScheduledStart) error {
+	if err := a.presets.setScheduledStart(&start); err != nil {
+		return err
+	}
+	a.runScheduler(start)
+	return nil
+}
+
+// CancelScheduledStart cancels the scheduled start, if there is one.
+func (a *application) CancelScheduledStart() {
+	a.scheduleMu.Lock()
+	if a.scheduler != nil {
+		a.scheduler.Cancel()
+	}
+	a.scheduler, a.scheduled = nil, nil
+	a.scheduleMu.Unlock()
+	if err := a.presets.setScheduledStart(nil); err != nil {
+		log.Printf("error cancelling schedule: %v\n", err)
+	}
+}
+
+// resumeScheduledStart carries on the recurring schedule saved when the app
+// was last open.
+func (a *application) resumeScheduledStart() {
+	if start := a.presets.scheduledStart(); start != nil {
+		a.runScheduler(*start)
+	}
+}
+
+// runScheduler replaces any running scheduler with one for start, showing
+// its countdown in the gui until it ends.
+func (a *application) runScheduler(start ScheduledStart) {
+	scheduler := internal.NewScheduler(start.Schedule)
+	a.scheduleMu.Lock()
+	if a.scheduler != nil {
+		a.scheduler.Cancel()
+	}
+	a.scheduler, a.scheduled = scheduler, &start
+	a.scheduleMu.Unlock()
+	a.gui.updateSchedule()
+
+	// the scheduler's updates are shown and its runs started from one
+	// goroutine, so that the gui isn't changed from the scheduler's
+	done, startC := make(chan struct{}), make(chan chan struct{})
+	go func() {
+		showUpdates := func() {
+			for len(scheduler.Updates()) > 0 {
+				a.showScheduleUpdate(scheduler, <-scheduler.Updates())
+			}
+		}
+		for {
+			select {
+			case update := <-scheduler.Updates():
+				a.showScheduleUpdate(scheduler, update)
+			case started := <-startC:
+				showUpdates()
+				a.startScheduled()
+				close(started)
+			case <-done:
+				// show the start or miss that ended the schedule
+				showUpdates()
+				a.endScheduler(scheduler)
+				return
+			}
+		}
+	}()
+
+	go func() {
+		scheduler.Run(func() {
+			started := make(chan struct{})
+			startC <- started
+			<-started
+			a.runs.Wait()
+		})
+		close(done)
+	}()
+}
+
+// endScheduler clears the scheduled start once scheduler has ended, unless
+// it has been replaced.
+func (a *application) endScheduler(scheduler *internal.Scheduler) {
+	a.scheduleMu.Lock()
+	current := a.scheduler == scheduler
+	if current {
+		a.scheduler, a.scheduled = nil, nil
+	}
+	a.scheduleMu.Unlock()
+	if current {
+		a.gui.updateSchedule()
+	}
+}
+
+// showScheduleUpdate shows the state of scheduler's next start in the gui,
+// telling the user about a missed start. Updates from a scheduler that has
+// been replaced are ignored.
+func (a *application) showScheduleUpdate(scheduler *internal.Scheduler, update internal.ScheduleUpdate) {
+	a.scheduleMu.Lock()
+	if a.scheduler != scheduler || a.scheduled == nil {
+		a.scheduleMu.Unlock()
+		return
+	}
+	start := *a.scheduled
+	a.scheduleMu.Unlock()
+
+	a.gui.updateScheduleCountdown(start, update)
+	if update.Missed {
+		log.Printf("error missed scheduled start at %v\n", update.Start)
+		a.gui.showMissedStart(update.Start)
+	}
+}
+
+// startScheduled starts the timer for the scheduled start, loading its
+// preset first.
+func (a *application) startScheduled() {
+	if a.running.Load() {
+		log.Printf("error starting scheduled run: the timer is already running\n")
+		return
+	}
+	if start := a.ScheduledStart(); start != nil && start.Preset != "" {
+		if err := a.LoadPreset(start.Preset); err != nil {
+			log.Printf("error starting scheduled run: %v\n", err)
+			return
+		}
+	}
+	a.gui.startScheduled()
+}
+
+// renameInSchedule updates the scheduled start when the preset called from
+// is renamed to to.
+func (a *application) renameInSchedule(from, to string) {
+	a.scheduleMu.Lock()
+	defer a.scheduleMu.Unlock()
+	if a.scheduled == nil || a.scheduled.Preset != from {
+		return
+	}
+	a.scheduled.Preset = to
+	if err := a.presets.setScheduledStart(a.scheduled); err != nil {
+		log.Printf("error updating schedule: %v\n", err)
+	}
+}
@@ -0,0 +1,48 @@
+package timer
+
+import (
+	"testing"
+	"time"
+
+	"fyne.io/fyne/v2/test"
+	"github.com/gabriel-ross/timer-go/internal"
+	"github.com/gabriel-ross/timer-go/preset"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduledStartPrefs(t *testing.T) {
+	library := presetLibrary{prefs: test.NewApp().Preferences()}
+	sprints := preset.New("Sprints")
+	sprints.Program = preset.Program{Intervals: 8, Work: preset.Duration(20 * time.Second), Rest: preset.Duration(10 * time.Second)}
+	require.NoError(t, library.save(sprints))
+	assert.Nil(t, library.scheduledStart())
+
+	weekdays := ScheduledStart{
+		Preset:   "Sprints",
+		Schedule: internal.Schedule{At: internal.TimeOfDay{Hour: 7}, Days: []time.Weekday{time.Monday, time.Wednesday, time.Friday}},
+	}
+	require.NoError(t, library.setScheduledStart(&weekdays))
+	assert.Equal(t, &weekdays, library.scheduledStart())
+	assert.Equal(t, "Sprints at 07:00 on Mon, Wed, Fri", weekdays.String())
+
+	assert.Error(t, library.setScheduledStart(&ScheduledStart{Preset: "Hills", Schedule: weekdays.Schedule}))
+	assert.Equal(t, &weekdays, library.scheduledStart())
+
+	// a single start isn't saved
+	once := ScheduledStart{Schedule: internal.Schedule{At: internal.TimeOfDay{Hour: 18, Second: 30}}}
+	require.NoError(t, library.setScheduledStart(&once))
+	assert.Nil(t, library.scheduledStart())
+	assert.Equal(t, "Current settings at 18:00:30", once.String())
+
+	require.NoError(t, library.setScheduledStart(&weekdays))
+	require.NoError(t, library.setScheduledStart(nil))
+	assert.Nil(t, library.scheduledStart())
+
+	// days outside the week are rejected when saved or read back
+	invalid := ScheduledStart{Schedule: internal.Schedule{At: internal.TimeOfDay{Hour: 7}, Days: []time.Weekday{7}}}
+	assert.Error(t, library.setScheduledStart(&invalid))
+	assert.Nil(t, library.scheduledStart())
+	library.prefs.SetString(PREF_SCHEDULE, `{"at": "07:00:00", "days": [7]}`)
+	assert.Nil(t, library.scheduledStart())
+}
@@ -0,0 +1,171 @@
+package timer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gabriel-ross/timer-go/internal"
+	"github.com/gabriel-ross/timer-go/preset"
+)
+
+const PREF_SESSION = "session"
+
+// Name of the rest between two presets in a session, followed by the name of
+// the next preset.
+var SESSION_TRANSITION_NAME = "Next: "
+
+// SessionItem is a preset from the preset library queued in a session.
+type SessionItem struct {
+	Preset     string        // Name of the preset in the preset library
+	Transition time.Duration // Rest before the next preset starts, ignored after the last
+}
+
+// sessionPref is a SessionItem as stored in the app's preferences.
+type sessionPref struct {
+	Preset     string          `json:"preset"`
+	Transition preset.Duration `json:"transition,omitempty"`
+}
+
+// session returns the presets queued in the session in order.
+func (l presetLibrary) session() []SessionItem {
+	saved := l.prefs.String(PREF_SESSION)
+	if saved == "" {
+		return nil
+	}
+	prefs := []sessionPref{}
+	if err := json.Unmarshal([]byte(saved), &prefs); err != nil {
+		log.Printf("error reading session: %v\n", err)
+		return nil
+	}
+	items := make([]SessionItem, len(prefs))
+	for i, pref := range prefs {
+		items[i] = SessionItem{Preset: pref.Preset, Transition: time.Duration(pref.Transition)}
+	}
+	return items
+}
+
+// setSession validates items and queues them as the session, replacing
+// the presets queued before.
+func (l presetLibrary) setSession(items []SessionItem) error {
+	docs := l.documents()
+	prefs := make([]sessionPref, len(items))
+	for i, item := range items {
+		if _, exists := docs[item.Preset]; !exists {
+			return fmt.Errorf("no preset called %q", item.Preset)
+		}
+		if item.Transition < 0 || item.Transition%time.Second != 0 {
+			return fmt.Errorf("the transition after %q must be a whole number of seconds", item.Preset)
+		}
+		prefs[i] = sessionPref{Preset: item.Preset, Transition: preset.Duration(item.Transition)}
+	}
+	if len(items) == 0 {
+		l.prefs.RemoveValue(PREF_SESSION)
+		return nil
+	}
+	data, err := json.Marshal(prefs)
+	if err != nil {
+		return fmt.Errorf("saving session: %w", err)
+	}
+	l.prefs.SetString(PREF_SESSION, string(data))
+	return nil
+}
+
+// renameInSession updates the presets queued in the session when the preset
+// called from is renamed to to.
+func (l presetLibrary) renameInSession(from, to string) {
+	items := l.session()
+	for i := range items {
+		if items[i].Preset == from {
+			items[i].Preset = to
+		}
+	}
+	if err := l.setSession(items); err != nil {
+		log.Printf("error updating session: %v\n", err)
+	}
+}
+
+// removeFromSession removes the preset called name from the session.
+func (l presetLibrary) removeFromSession(name string) {
+	items := []SessionItem{}
+	for _, item := range l.session() {
+		if item.Preset != name {
+			items = append(items, item)
+		}
+	}
+	if err := l.setSession(items); err != nil {
+		log.Printf("error updating session: %v\n", err)
+	}
+}
+
+// sessionSegments returns the engine segments that run the programs of the
+// presets in items back to back, with a rest for each transition. Each
+// segment's part is the name of the preset it belongs to. Shuffled groups
+// are ordered by seed.
+func (l presetLibrary) sessionSegments(items []SessionItem, seed int64) ([]internal.Segment, error) {
+	segments := []internal.Segment{}
+	var errs []error
+	for i, item := range items {
+		p, err := l.get(item.Preset)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, segment := range programSegments(p.Program, seed+int64(i)) {
+			segment.Part = item.Preset
+			segments = append(segments, segment)
+		}
+		if item.Transition > 0 && i < len(items)-1 {
+			segments = append(segments, internal.Segment{
+				Name:     SESSION_TRANSITION_NAME + items[i+1].Preset,
+				Phase:    internal.RestPhase,
+				Duration: item.Transition,
+				Part:     item.Preset,
+			})
+		}
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("running session: %w", errors.Join(errs...))
+	}
+	return segments, nil
+}
+
+// Session returns the presets queued to run back to back as one session.
+func (a *application) Session() []SessionItem {
+	return a.presets.session()
+}
+
+// SetSession queues presets from the preset library to run back to back as
+// one session. While presets are queued the timer runs the session in place
+// of the program, with the current sounds, cues, music and time cap. An
+// empty session runs the program again.
+func (a *application) SetSession(items []SessionItem) error {
+	return a.presets.setSession(items)
+}
+
+// SessionSteps returns the steps of a session of items for previewing it.
+// Shuffled groups are in the order of an arbitrary seed.
+func (a *application) SessionSteps(items []SessionItem) ([]preset.Segment, error) {
+	segments, err := a.presets.sessionSegments(items, 0)
+	if err != nil {
+		return nil, err
+	}
+	steps := make([]preset.Segment, len(segments))
+	for i, segment := range segments {
+		steps[i] = preset.Segment{
+			Name:        segment.Name,
+			Phase:       preset.Work,
+			Duration:    preset.Duration(segment.Duration),
+			MaxDuration: preset.Duration(segment.MaxDuration),
+		}
+		if m := segment.Metronome; m != nil {
+			steps[i].Metronome = &preset.Metronome{StartBPM: m.StartBPM, EndBPM: m.EndBPM}
+		}
+		if segment.Phase == internal.RestPhase {
+			steps[i].Phase = preset.Rest
+		}
+	}
+	return steps, nil
+}
@@ -0,0 +1,54 @@
+package timer
+
+import (
+	"testing"
+	"time"
+
+	"fyne.io/fyne/v2/test"
+	"github.com/gabriel-ross/timer-go/internal"
+	"github.com/gabriel-ross/timer-go/preset"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSession(t *testing.T) {
+	library := presetLibrary{prefs: test.NewApp().Preferences()}
+	warmUp := preset.New("Warm up")
+	warmUp.Program = preset.Program{Intervals: 2, Work: preset.Duration(30 * time.Second)}
+	require.NoError(t, library.save(warmUp))
+	stretch := preset.New("Stretch")
+	stretch.Program = preset.Program{Segments: []preset.Segment{{Name: "Hamstrings", Phase: preset.Rest, Duration: preset.Duration(time.Minute)}}}
+	require.NoError(t, library.save(stretch))
+	assert.Empty(t, library.session())
+
+	items := []SessionItem{{Preset: "Warm up", Transition: 15 * time.Second}, {Preset: "Stretch", Transition: time.Minute}}
+	require.NoError(t, library.setSession(items))
+	assert.Equal(t, items, library.session())
+	assert.Error(t, library.setSession([]SessionItem{{Preset: "Sprints"}}))
+	assert.Error(t, library.setSession([]SessionItem{{Preset: "Stretch", Transition: 1500 * time.Millisecond}}))
+	assert.Equal(t, items, library.session())
+
+	segments, err := library.sessionSegments(library.session(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, []internal.Segment{
+		{Phase: internal.WorkPhase, Duration: 30 * time.Second, Part: "Warm up"},
+		{Phase: internal.WorkPhase, Duration: 30 * time.Second, Part: "Warm up"},
+		{Name: "Next: Stretch", Phase: internal.RestPhase, Duration: 15 * time.Second, Part: "Warm up"},
+		{Name: "Hamstrings", Phase: internal.RestPhase, Duration: time.Minute, Part: "Stretch"},
+	}, segments)
+
+	require.NoError(t, library.rename("Stretch", "Cool down"))
+	library.renameInSession("Stretch", "Cool down")
+	assert.Equal(t, "Cool down", library.session()[1].Preset)
+	segments, err = library.sessionSegments(library.session(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, "Cool down", segments[3].Part)
+	require.NoError(t, library.remove("Warm up"))
+	_, err = library.sessionSegments(library.session(), 1)
+	assert.Error(t, err)
+	library.removeFromSession("Warm up")
+	assert.Equal(t, []SessionItem{{Preset: "Cool down", Transition: time.Minute}}, library.session())
+
+	require.NoError(t, library.setSession(nil))
+	assert.Empty(t, library.session())
+}
@@ -0,0 +1,167 @@
+package timer
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/faiface/beep"
+)
+
+// SOUND_PACK_MANIFEST is the name of the manifest at the root of a sound
+// pack.
+//
+// A sound pack is a folder or zip file containing a themed set of sounds and
+// a JSON manifest mapping cue roles to the path of the sound played for
+// each:
+//
+//	{
+//		"name": "Dojo",
+//		"sounds": {
+//			"work-start": "gong.wav",
+//			"rest-start": "bell.ogg",
+//			"countdown": "tick.wav",
+//			"interval-end": "clap.mp3",
+//			"finish": "fanfare.flac"
+//		}
+//	}
+//
+// A pack doesn't need a sound for every role in SOUND_PACK_ROLES. Roles
+// without a sound play nothing while the pack is in use.
+var SOUND_PACK_MANIFEST = "soundpack.json"
+
+// CueRole is an event during a timer that a sound can be played for.
+type CueRole string
+
+const (
+	WorkStartRole   CueRole = "work-start"   // A work interval starts
+	RestStartRole   CueRole = "rest-start"   // A rest interval starts
+	CountdownRole   CueRole = "countdown"    // Each second of the countdown to the end of an interval
+	IntervalEndRole CueRole = "interval-end" // An interval ends
+	FinishRole      CueRole = "finish"       // The timer finishes
+	BeatRole        CueRole = "beat"         // A metronome beats
+)
+
+// SOUND_PACK_ROLES are the roles a sound pack can provide sounds for.
+var SOUND_PACK_ROLES = []CueRole{WorkStartRole, RestStartRole, CountdownRole, IntervalEndRole, FinishRole}
+
+type soundPackManifest struct {
+	Name   string             `json:"name"`
+	Sounds map[CueRole]string `json:"sounds"`
+}
+
+// soundPack is a set of sounds for cue roles decoded into memory.
+type soundPack struct {
+	name   string
+	sounds map[CueRole]audioStream
+}
+
+// loadSoundPack decodes the sound pack in the folder or zip file at path at
+// the sample rate sr. Returns an error if the manifest is missing or invalid,
+// names a role that isn't in SOUND_PACK_ROLES, or any sound can't be
+// decoded.
+func loadSoundPack(sr beep.SampleRate, path string) (*soundPack, error) {
+	var fsys fs.FS
+	if strings.EqualFold(filepath.Ext(path), ".zip") {
+		zipFS, err := zip.OpenReader(path)
+		if err != nil {
+			return nil, fmt.Errorf("opening sound pack %s: %w", path, err)
+		}
+		defer zipFS.Close()
+		fsys = zipFS
+	} else {
+		fsys = os.DirFS(path)
+	}
+
+	data, err := fs.ReadFile(fsys, SOUND_PACK_MANIFEST)
+	if err != nil {
+		return nil, fmt.Errorf("reading sound pack manifest in %s: %w", path, err)
+	}
+	var manifest soundPackManifest
+	if err = json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("parsing sound pack manifest in %s: %w", path, err)
+	}
+	if strings.TrimSpace(manifest.Name) == "" {
+		return nil, fmt.Errorf("sound pack in %s has no name", path)
+	}
+	if len(manifest.Sounds) == 0 {
+		return nil, fmt.Errorf("sound pack %q has no sounds", manifest.Name)
+	}
+
+	pack := &soundPack{
+		name:   manifest.Name,
+		sounds: map[CueRole]audioStream{},
+	}
+	for role, soundPath := range manifest.Sounds {
+		if !isSoundPackRole(role) {
+			return nil, fmt.Errorf("sound pack %q has a sound for unknown role %q", manifest.Name, role)
+		}
+		stream, err := newFSAudioStream(sr, fsys, soundPath)
+		if err != nil {
+			return nil, fmt.Errorf("loading %s sound of sound pack %q: %w", role, manifest.Name, err)
+		}
+		pack.sounds[role] = stream
+	}
+	return pack, nil
+}
+
+// loadSoundPacks loads every sound pack in dir, which are the folders
+// containing a SOUND_PACK_MANIFEST and the zip files. Returns the packs that
+// loaded sorted by name, and an error for each pack that didn't. A dir that
+// doesn't exist contains no packs.
+func loadSoundPacks(sr beep.SampleRate, dir string) ([]*soundPack, []error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, []error{fmt.Errorf("reading sound packs: %w", err)}
+	}
+
+	packs := []*soundPack{}
+	names := map[string]bool{}
+	var errs []error
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		if entry.IsDir() {
+			if _, err := os.Stat(filepath.Join(path, SOUND_PACK_MANIFEST)); err != nil {
+				continue
+			}
+		} else if !strings.EqualFold(filepath.Ext(entry.Name()), ".zip") {
+			continue
+		}
+		pack, err := loadSoundPack(sr, path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if names[pack.name] {
+			errs = append(errs, fmt.Errorf("sound pack in %s has the same name as another pack, %q", path, pack.name))
+			continue
+		}
+		names[pack.name] = true
+		packs = append(packs, pack)
+	}
+	sort.Slice(packs, func(i, j int) bool { return packs[i].name < packs[j].name })
+	return packs, errs
+}
+
+// registeredName returns the name the pack's sound for role is registered
+// as.
+func (p *soundPack) registeredName(role CueRole) string {
+	return fmt.Sprintf("%s: %s", p.name, role)
+}
+
+func isSoundPackRole(role CueRole) bool {
+	for _, r := range SOUND_PACK_ROLES {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package timer
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeSoundPack writes a sound pack folder with the given manifest and a
+// clip of n samples at each of paths.
+func writeSoundPack(t *testing.T, dir, manifest string, n int, paths ...string) {
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, SOUND_PACK_MANIFEST), []byte(manifest), 0644))
+	for _, path := range paths {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, path)), 0755))
+		writeWav(t, filepath.Join(dir, path), 0.5, n)
+	}
+}
+
+// writeSoundPackZip writes a zip sound pack with the given manifest and a
+// clip of n samples at each of paths.
+func writeSoundPackZip(t *testing.T, path, manifest string, n int, paths ...string) {
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+	w := zip.NewWriter(f)
+	files := map[string][]byte{SOUND_PACK_MANIFEST: []byte(manifest)}
+	for _, path := range paths {
+		files[path] = encodeWav(t, n)
+	}
+	for name, data := range files {
+		fw, err := w.Create(name)
+		require.NoError(t, err)
+		_, err = fw.Write(data)
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+}
+
+func TestLoadSoundPacks(t *testing.T) {
+	dir := t.TempDir()
+	writeSoundPack(t, filepath.Join(dir, "dojo"), `{"name": "Dojo", "sounds": {"work-start": "gong.wav", "finish": "bells/finish.wav"}}`, 100, "gong.wav", "bells/finish.wav")
+	writeSoundPackZip(t, filepath.Join(dir, "arcade.zip"), `{"name": "Arcade", "sounds": {"countdown": "blip.wav"}}`, 50, "blip.wav")
+	writeSoundPack(t, filepath.Join(dir, "broken"), `{"name": "Broken", "sounds": {"work-start": "missing.wav"}}`, 100)
+	writeSoundPack(t, filepath.Join(dir, "unknown"), `{"name": "Unknown", "sounds": {"lunch": "gong.wav"}}`, 100, "gong.wav")
+	writeSoundPack(t, filepath.Join(dir, "zz copy"), `{"name": "Dojo", "sounds": {"finish": "gong.wav"}}`, 100, "gong.wav")
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "not a pack"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "readme.txt"), []byte("hi"), 0644))
+
+	packs, errs := loadSoundPacks(1000, dir)
+	assert.Len(t, errs, 3, "missing sound, unknown role and duplicate name")
+	if assert.Len(t, packs, 2) {
+		assert.Equal(t, "Arcade", packs[0].name)
+		assert.Equal(t, 1, len(packs[0].sounds))
+		assert.Equal(t, 50, packs[0].sounds[CountdownRole].buffer.Len())
+		assert.Equal(t, "Dojo: countdown", packs[1].registeredName(CountdownRole))
+		assert.Equal(t, 2, len(packs[1].sounds))
+		assert.Equal(t, 100, packs[1].sounds[FinishRole].buffer.Len())
+	}
+
+	packs, errs = loadSoundPacks(1000, filepath.Join(dir, "missing"))
+	assert.Empty(t, packs)
+	assert.Empty(t, errs)
+}
+
+func TestLoadSoundPackErrors(t *testing.T) {
+	dir := t.TempDir()
+	_, err := loadSoundPack(1000, dir)
+	assert.Error(t, err, "no manifest")
+
+	writeSoundPack(t, filepath.Join(dir, "nameless"), `{"sounds": {"finish": "a.wav"}}`, 10, "a.wav")
+	_, err = loadSoundPack(1000, filepath.Join(dir, "nameless"))
+	assert.Error(t, err)
+
+	writeSoundPack(t, filepath.Join(dir, "empty"), `{"name": "Empty"}`, 10)
+	_, err = loadSoundPack(1000, filepath.Join(dir, "empty"))
+	assert.Error(t, err)
+
+	writeSoundPack(t, filepath.Join(dir, "invalid"), `{"name": `, 10)
+	_, err = loadSoundPack(1000, filepath.Join(dir, "invalid"))
+	assert.Error(t, err)
+}
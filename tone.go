@@ -0,0 +1,193 @@
+package timer
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/faiface/beep"
+)
+
+// Waveform is the shape of the wave used to synthesize a Tone.
+type Waveform int
+
+const (
+	SineWave Waveform = iota
+	SquareWave
+)
+
+// Tone describes a synthesized cue made up of one or more identical beeps.
+type Tone struct {
+	Waveform  Waveform
+	Frequency float64       // Pitch of each beep in Hz
+	Duration  time.Duration // Length of each beep
+	Count     int           // Number of beeps, defaults to 1
+	Gap       time.Duration // Silence between beeps
+	Attack    time.Duration // Time taken for each beep to fade in
+	Release   time.Duration // Time taken for each beep to fade out
+	Amplitude float64       // Peak amplitude between 0 and 1, defaults to 1
+}
+
+// DEFAULT_TONES are the synthesized sounds registered with every
+// application, so that cues are available without any audio files.
+var DEFAULT_TONES = map[string]Tone{
+	"Triple Beep": {
+		Waveform:  SineWave,
+		Frequency: 1760,
+		Duration:  120 * time.Millisecond,
+		Count:     3,
+		Gap:       180 * time.Millisecond,
+		Attack:    5 * time.Millisecond,
+		Release:   20 * time.Millisecond,
+		Amplitude: 0.6,
+	},
+	"Low Tone": {
+		Waveform:  SineWave,
+		Frequency: 330,
+		Duration:  900 * time.Millisecond,
+		Count:     1,
+		Attack:    20 * time.Millisecond,
+		Release:   300 * time.Millisecond,
+		Amplitude: 0.7,
+	},
+	"Click": {
+		Waveform:  SquareWave,
+		Frequency: 1500,
+		Duration:  25 * time.Millisecond,
+		Count:     1,
+		Attack:    1 * time.Millisecond,
+		Release:   15 * time.Millisecond,
+		Amplitude: 0.4,
+	},
+	"Buzzer": {
+		Waveform:  SquareWave,
+		Frequency: 220,
+		Duration:  400 * time.Millisecond,
+		Count:     2,
+		Gap:       100 * time.Millisecond,
+		Attack:    5 * time.Millisecond,
+		Release:   30 * time.Millisecond,
+		Amplitude: 0.3,
+	},
+}
+
+// validate returns an error if the tone can't be synthesized.
+func (t Tone) validate() error {
+	switch {
+	case t.Frequency <= 0:
+		return errors.New("tone frequency must be positive")
+	case t.Duration <= 0:
+		return errors.New("tone duration must be positive")
+	case t.Count < 0:
+		return errors.New("tone count must not be negative")
+	case t.Gap < 0 || t.Attack < 0 || t.Release < 0:
+		return errors.New("tone gap, attack and release must not be negative")
+	case t.Attack+t.Release > t.Duration:
+		return errors.New("tone attack and release must fit within its duration")
+	case t.Amplitude < 0 || t.Amplitude > 1:
+		return errors.New("tone amplitude must be between 0 and 1")
+	}
+	return nil
+}
+
+// toneStream is a beep.StreamSeekCloser that synthesizes a Tone at a fixed
+// sample rate. Samples are generated on demand from the stream position so
+// no audio is held in memory.
+type toneStream struct {
+	tone       Tone
+	sampleRate beep.SampleRate
+	beepLen    int // Samples in one beep
+	period     int // Samples in one beep plus the following gap
+	attackLen  int
+	releaseLen int
+	len        int
+	pos        int
+}
+
+// newToneStream returns a toneStream synthesizing tone at sample rate sr.
+// Returns an error if the tone is invalid.
+func newToneStream(sr beep.SampleRate, tone Tone) (*toneStream, error) {
+	if err := tone.validate(); err != nil {
+		return nil, err
+	}
+	if tone.Count == 0 {
+		tone.Count = 1
+	}
+	if tone.Amplitude == 0 {
+		tone.Amplitude = 1
+	}
+
+	ts := &toneStream{
+		tone:       tone,
+		sampleRate: sr,
+		beepLen:    sr.N(tone.Duration),
+		attackLen:  sr.N(tone.Attack),
+		releaseLen: sr.N(tone.Release),
+	}
+	ts.period = ts.beepLen + sr.N(tone.Gap)
+	ts.len = tone.Count*ts.period - sr.N(tone.Gap)
+	return ts, nil
+}
+
+func (ts *toneStream) Stream(samples [][2]float64) (n int, ok bool) {
+	if ts.pos >= ts.len {
+		return 0, false
+	}
+	for i := range samples {
+		if ts.pos >= ts.len {
+			break
+		}
+		v := ts.sample(ts.pos)
+		samples[i] = [2]float64{v, v}
+		ts.pos++
+		n++
+	}
+	return n, true
+}
+
+// sample returns the value of the tone at sample position pos.
+func (ts *toneStream) sample(pos int) float64 {
+	offset := pos % ts.period
+	if offset >= ts.beepLen {
+		return 0
+	}
+
+	phase := 2 * math.Pi * ts.tone.Frequency * float64(offset) / float64(ts.sampleRate)
+	v := math.Sin(phase)
+	if ts.tone.Waveform == SquareWave {
+		v = math.Copysign(1, v)
+	}
+
+	envelope := 1.0
+	if ts.attackLen > 0 && offset < ts.attackLen {
+		envelope = float64(offset) / float64(ts.attackLen)
+	}
+	if remaining := ts.beepLen - offset; ts.releaseLen > 0 && remaining < ts.releaseLen {
+		envelope = math.Min(envelope, float64(remaining)/float64(ts.releaseLen))
+	}
+	return v * envelope * ts.tone.Amplitude
+}
+
+func (ts *toneStream) Err() error {
+	return nil
+}
+
+func (ts *toneStream) Len() int {
+	return ts.len
+}
+
+func (ts *toneStream) Position() int {
+	return ts.pos
+}
+
+func (ts *toneStream) Seek(p int) error {
+	if p < 0 || p > ts.len {
+		return errors.New("tone seek position out of range")
+	}
+	ts.pos = p
+	return nil
+}
+
+func (ts *toneStream) Close() error {
+	return nil
+}
@@ -0,0 +1,84 @@
+package timer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/faiface/beep"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToneStream(t *testing.T) {
+	sr := beep.SampleRate(1000)
+	ts, err := newToneStream(sr, Tone{
+		Waveform:  SquareWave,
+		Frequency: 100,
+		Duration:  100 * time.Millisecond,
+		Count:     3,
+		Gap:       50 * time.Millisecond,
+		Amplitude: 0.5,
+	})
+	require.NoError(t, err)
+
+	// three 100 sample beeps separated by two 50 sample gaps
+	assert.Equal(t, 400, ts.Len())
+
+	samples := make([][2]float64, 1000)
+	n, ok := ts.Stream(samples)
+	assert.True(t, ok)
+	assert.Equal(t, 400, n)
+	for i, s := range samples[:n] {
+		assert.LessOrEqual(t, s[0], 0.5)
+		assert.GreaterOrEqual(t, s[0], -0.5)
+		if offset := i % 150; offset >= 100 {
+			assert.Zerof(t, s[0], "expected silence at sample %d", i)
+		}
+	}
+
+	n, ok = ts.Stream(samples)
+	assert.False(t, ok)
+	assert.Zero(t, n)
+
+	require.NoError(t, ts.Seek(0))
+	assert.Equal(t, 0, ts.Position())
+	assert.Error(t, ts.Seek(401))
+}
+
+func TestToneEnvelope(t *testing.T) {
+	sr := beep.SampleRate(1000)
+	ts, err := newToneStream(sr, Tone{
+		Waveform:  SquareWave,
+		Frequency: 100,
+		Duration:  100 * time.Millisecond,
+		Attack:    10 * time.Millisecond,
+		Release:   10 * time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	assert.Zero(t, ts.sample(0))
+	assert.InDelta(t, 0.2, ts.sample(2), 1e-9)
+	assert.Equal(t, 1.0, ts.sample(11))
+	assert.InDelta(t, 0.8, ts.sample(92), 1e-9)
+}
+
+func TestToneValidate(t *testing.T) {
+	valid := Tone{Frequency: 440, Duration: time.Second}
+	assert.NoError(t, valid.validate())
+
+	tests := map[string]func(*Tone){
+		"zero frequency":         func(tone *Tone) { tone.Frequency = 0 },
+		"zero duration":          func(tone *Tone) { tone.Duration = 0 },
+		"negative count":         func(tone *Tone) { tone.Count = -1 },
+		"negative gap":           func(tone *Tone) { tone.Gap = -time.Second },
+		"envelope too long":      func(tone *Tone) { tone.Attack, tone.Release = time.Second, time.Second },
+		"amplitude out of range": func(tone *Tone) { tone.Amplitude = 2 },
+	}
+	for name, modify := range tests {
+		t.Run(name, func(t *testing.T) {
+			tone := valid
+			modify(&tone)
+			assert.Error(t, tone.validate())
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package timer
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/faiface/beep"
+)
+
+// SoundTrim selects the part of a sound that is played and how it fades in
+// and out.
+type SoundTrim struct {
+	Start   time.Duration // Offset into the sound at which playback starts
+	End     time.Duration // Offset into the sound at which playback ends, zero plays to the end
+	FadeIn  time.Duration // Time taken to fade in from Start
+	FadeOut time.Duration // Time taken to fade out before End
+}
+
+// trim returns the sound's trim.
+func (s audioStream) trim() SoundTrim {
+	sr := s.format.SampleRate
+	trim := SoundTrim{
+		Start:   sr.D(s.startPos),
+		FadeIn:  sr.D(s.fadeIn),
+		FadeOut: sr.D(s.fadeOut),
+	}
+	if s.endPos != 0 {
+		trim.End = sr.D(s.endPos)
+	}
+	return trim
+}
+
+// setTrim trims the sound to trim. Returns an error, leaving the sound
+// unchanged, if trim doesn't fit within the sound.
+func (s *audioStream) setTrim(trim SoundTrim) error {
+	sr := s.format.SampleRate
+	start, end := sr.N(trim.Start), s.buffer.Len()
+	if trim.End != 0 {
+		end = sr.N(trim.End)
+	}
+	switch {
+	case trim.Start < 0 || trim.End < 0 || trim.FadeIn < 0 || trim.FadeOut < 0:
+		return errors.New("sound trim must not be negative")
+	case end > s.buffer.Len():
+		return errors.New("sound trim must end within the sound")
+	case start > end:
+		return errors.New("sound trim must start before it ends")
+	case sr.N(trim.FadeIn)+sr.N(trim.FadeOut) > end-start:
+		return errors.New("sound fades must fit within the trimmed sound")
+	}
+
+	s.startPos = start
+	s.endPos = 0
+	if trim.End != 0 {
+		s.endPos = end
+	}
+	s.fadeIn = sr.N(trim.FadeIn)
+	s.fadeOut = sr.N(trim.FadeOut)
+	return nil
+}
+
+// length returns the length of the whole sound, ignoring any trim.
+func (s audioStream) length() time.Duration {
+	return s.format.SampleRate.D(s.buffer.Len())
+}
+
+// fader is a beep.Streamer that linearly fades in the first fadeIn samples of
+// a streamer of len samples and fades out the last fadeOut.
+type fader struct {
+	streamer beep.Streamer
+	len      int
+	fadeIn   int
+	fadeOut  int
+	pos      int
+}
+
+func (f *fader) Stream(samples [][2]float64) (n int, ok bool) {
+	n, ok = f.streamer.Stream(samples)
+	for i := range samples[:n] {
+		gain := fadeGain(f.pos, f.len, f.fadeIn, f.fadeOut)
+		samples[i][0] *= gain
+		samples[i][1] *= gain
+		f.pos++
+	}
+	return n, ok
+}
+
+func (f *fader) Err() error {
+	return f.streamer.Err()
+}
+
+// fadeGain returns the gain at sample pos of a sound of length samples that
+// fades in over fadeIn samples and out over fadeOut.
+func fadeGain(pos, length, fadeIn, fadeOut int) float64 {
+	gain := 1.0
+	if fadeIn > 0 && pos < fadeIn {
+		gain = float64(pos) / float64(fadeIn)
+	}
+	if remaining := length - pos; fadeOut > 0 && remaining < fadeOut {
+		gain = math.Min(gain, float64(remaining)/float64(fadeOut))
+	}
+	return gain
+}
+
+// waveformPeaks divides the whole of buffer into n equal buckets and returns
+// the peak absolute amplitude of each, for drawing a waveform.
+func waveformPeaks(buffer *beep.Buffer, n int) []float64 {
+	peaks := make([]float64, n)
+	if n == 0 || buffer.Len() == 0 {
+		return peaks
+	}
+	samples := make([][2]float64, 512)
+	streamer := buffer.Streamer(0, buffer.Len())
+	pos := 0
+	for {
+		sn, ok := streamer.Stream(samples)
+		for _, sample := range samples[:sn] {
+			bucket := pos * n / buffer.Len()
+			peak := math.Max(math.Abs(sample[0]), math.Abs(sample[1]))
+			if peak > peaks[bucket] {
+				peaks[bucket] = peak
+			}
+			pos++
+		}
+		if !ok || sn == 0 {
+			return peaks
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package timer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/faiface/beep"
+	"github.com/stretchr/testify/assert"
+)
+
+// readAll reads every sample from s.
+func readAll(s beep.Streamer) [][2]float64 {
+	all := [][2]float64{}
+	samples := make([][2]float64, 64)
+	for {
+		n, ok := s.Stream(samples)
+		all = append(all, samples[:n]...)
+		if !ok || n == 0 {
+			return all
+		}
+	}
+}
+
+func TestSoundTrim(t *testing.T) {
+	// one sample per millisecond
+	stream := bufferStream(1000, constant(0.5, 1000))
+	trim := SoundTrim{
+		Start:   100 * time.Millisecond,
+		End:     600 * time.Millisecond,
+		FadeIn:  100 * time.Millisecond,
+		FadeOut: 200 * time.Millisecond,
+	}
+	assert.NoError(t, stream.setTrim(trim))
+	assert.Equal(t, trim, stream.trim())
+
+	samples := readAll(stream.streamer())
+	if assert.Len(t, samples, 500) {
+		assert.InDelta(t, 0, samples[0][0], 0.001)
+		assert.InDelta(t, 0.25, samples[50][0], 0.001)
+		assert.InDelta(t, 0.5, samples[200][0], 0.001)
+		assert.InDelta(t, 0.25, samples[400][0], 0.001)
+		assert.InDelta(t, 0.0025, samples[499][1], 0.001)
+	}
+
+	// a zero end plays to the end of the sound
+	assert.NoError(t, stream.setTrim(SoundTrim{Start: 900 * time.Millisecond}))
+	assert.Len(t, readAll(stream.streamer()), 100)
+}
+
+func TestInvalidSoundTrim(t *testing.T) {
+	stream := bufferStream(1000, constant(0.5, 1000))
+	valid := SoundTrim{Start: 100 * time.Millisecond}
+	assert.NoError(t, stream.setTrim(valid))
+
+	for name, trim := range map[string]SoundTrim{
+		"negative":           {Start: -time.Millisecond},
+		"past end":           {End: 2 * time.Second},
+		"starts after end":   {Start: 500 * time.Millisecond, End: 400 * time.Millisecond},
+		"fades too long":     {FadeIn: 600 * time.Millisecond, FadeOut: 600 * time.Millisecond},
+		"fades beyond trim":  {Start: 900 * time.Millisecond, FadeIn: 200 * time.Millisecond},
+		"negative fade":      {FadeOut: -time.Millisecond},
+		"start past the end": {Start: 2 * time.Second},
+	} {
+		assert.Error(t, stream.setTrim(trim), name)
+		assert.Equal(t, valid, stream.trim(), name)
+	}
+}
+
+func TestWaveformPeaks(t *testing.T) {
+	stream := bufferStream(1000, beep.Seq(constant(0.25, 100), constant(-0.75, 100), constant(0, 200)))
+	peaks := waveformPeaks(stream.buffer, 4)
+	expected := []float64{0.25, 0.75, 0, 0}
+	if assert.Len(t, peaks, len(expected)) {
+		for i := range expected {
+			assert.InDelta(t, expected[i], peaks[i], 0.001)
+		}
+	}
+	assert.Equal(t, []float64{0, 0}, waveformPeaks(nilAudioStream(1000).buffer, 2))
+}
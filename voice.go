@@ -0,0 +1,140 @@
+package timer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"strings"
+	"time"
+
+	"github.com/faiface/beep"
+	"github.com/gabriel-ross/timer-go/internal"
+)
+
+// VOICE_MANIFEST is the name of the manifest at the root of a voice pack.
+//
+// A voice pack is a folder, or any fs.FS such as an embed.FS, containing a
+// clip of each word it can speak and a JSON manifest mapping each word to
+// the path of its clip:
+//
+//	{
+//		"name": "Coach",
+//		"clips": {
+//			"one": "numbers/one.wav",
+//			"rest": "rest.mp3",
+//			"round": "round.mp3"
+//		}
+//	}
+//
+// Announcements use the words "rest", "round", "halfway" and the numbers
+// "zero" to "nineteen", "twenty" to "ninety" and "hundred".
+var VOICE_MANIFEST = "voice.json"
+
+// Silence between words when speaking a phrase.
+var VOICE_WORD_GAP = 40 * time.Millisecond
+
+type voiceManifest struct {
+	Name  string            `json:"name"`
+	Clips map[string]string `json:"clips"`
+}
+
+// voicePack is a set of spoken word clips decoded into memory.
+type voicePack struct {
+	name       string
+	sampleRate beep.SampleRate
+	clips      map[string]*beep.Buffer
+}
+
+// loadVoicePack decodes the voice pack in fsys at the sample rate sr.
+// Returns an error if the manifest is missing or invalid, or any clip can't
+// be decoded.
+func loadVoicePack(sr beep.SampleRate, fsys fs.FS) (*voicePack, error) {
+	data, err := fs.ReadFile(fsys, VOICE_MANIFEST)
+	if err != nil {
+		return nil, fmt.Errorf("reading voice pack manifest: %w", err)
+	}
+	var manifest voiceManifest
+	if err = json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("parsing voice pack manifest: %w", err)
+	}
+	if len(manifest.Clips) == 0 {
+		return nil, fmt.Errorf("voice pack %q has no clips", manifest.Name)
+	}
+
+	pack := &voicePack{
+		name:       manifest.Name,
+		sampleRate: sr,
+		clips:      map[string]*beep.Buffer{},
+	}
+	for word, path := range manifest.Clips {
+		clip, err := newFSAudioStream(sr, fsys, path)
+		if err != nil {
+			return nil, fmt.Errorf("loading clip for %q: %w", word, err)
+		}
+		pack.clips[strings.ToLower(word)] = clip.buffer
+	}
+	return pack, nil
+}
+
+// phrase sequences the clips of words into a new audioStream. Returns an
+// error if the pack has no clip for any of the words.
+func (v *voicePack) phrase(words ...string) (audioStream, error) {
+	streamers := []beep.Streamer{}
+	for i, word := range words {
+		clip, exists := v.clips[strings.ToLower(word)]
+		if !exists {
+			return audioStream{}, fmt.Errorf("voice pack %q can't say %q", v.name, word)
+		}
+		if i > 0 {
+			streamers = append(streamers, beep.Silence(v.sampleRate.N(VOICE_WORD_GAP)))
+		}
+		streamers = append(streamers, clip.Streamer(0, clip.Len()))
+	}
+	stream := bufferStream(v.sampleRate, beep.Seq(streamers...))
+	stream.name = strings.Join(words, " ")
+	return stream, nil
+}
+
+// announcementWords returns the words spoken for an announcement.
+func announcementWords(a internal.Announcement) []string {
+	switch a.Kind {
+	case internal.CountdownAnnouncement:
+		return numberWords(a.Number)
+	case internal.HalfwayAnnouncement:
+		return []string{"halfway"}
+	case internal.WorkAnnouncement:
+		return append([]string{"round"}, numberWords(a.Number)...)
+	case internal.RestAnnouncement:
+		return []string{"rest"}
+	default:
+		return nil
+	}
+}
+
+var (
+	ONES = []string{"zero", "one", "two", "three", "four", "five", "six", "seven", "eight", "nine",
+		"ten", "eleven", "twelve", "thirteen", "fourteen", "fifteen", "sixteen", "seventeen", "eighteen", "nineteen"}
+	TENS = []string{"", "", "twenty", "thirty", "forty", "fifty", "sixty", "seventy", "eighty", "ninety"}
+)
+
+// numberWords returns the words spoken for n. Returns nil if n is not
+// between 0 and 999.
+func numberWords(n int) []string {
+	switch {
+	case n < 0 || n > 999:
+		return nil
+	case n < 20:
+		return []string{ONES[n]}
+	case n < 100:
+		if n%10 == 0 {
+			return []string{TENS[n/10]}
+		}
+		return []string{TENS[n/10], ONES[n%10]}
+	default:
+		words := []string{ONES[n/100], "hundred"}
+		if n%100 == 0 {
+			return words
+		}
+		return append(words, numberWords(n%100)...)
+	}
+}
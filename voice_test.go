@@ -0,0 +1,66 @@
+package timer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/gabriel-ross/timer-go/internal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// encodeWav returns a WAV file of n samples.
+func encodeWav(t *testing.T, n int) []byte {
+	path := filepath.Join(t.TempDir(), "clip.wav")
+	writeWav(t, path, 0.5, n)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return data
+}
+
+func TestVoicePackPhrase(t *testing.T) {
+	fsys := fstest.MapFS{
+		"voice.json":       {Data: []byte(`{"name": "Test", "clips": {"round": "round.wav", "Five": "numbers/five.wav"}}`)},
+		"round.wav":        {Data: encodeWav(t, 100)},
+		"numbers/five.wav": {Data: encodeWav(t, 50)},
+	}
+	pack, err := loadVoicePack(1000, fsys)
+	require.NoError(t, err)
+
+	stream, err := pack.phrase("round", "five")
+	require.NoError(t, err)
+	assert.Equal(t, "round five", stream.name)
+	assert.Equal(t, 100+pack.sampleRate.N(VOICE_WORD_GAP)+50, stream.buffer.Len())
+
+	_, err = pack.phrase("rest")
+	assert.Error(t, err)
+}
+
+func TestLoadVoicePackErrors(t *testing.T) {
+	_, err := loadVoicePack(1000, fstest.MapFS{})
+	assert.Error(t, err, "missing manifest")
+
+	_, err = loadVoicePack(1000, fstest.MapFS{
+		"voice.json": {Data: []byte(`{"clips": {"one": "one.wav"}}`)},
+	})
+	assert.Error(t, err, "missing clip")
+}
+
+func TestNumberWords(t *testing.T) {
+	assert.Equal(t, []string{"seven"}, numberWords(7))
+	assert.Equal(t, []string{"fifteen"}, numberWords(15))
+	assert.Equal(t, []string{"forty"}, numberWords(40))
+	assert.Equal(t, []string{"forty", "two"}, numberWords(42))
+	assert.Equal(t, []string{"one", "hundred"}, numberWords(100))
+	assert.Equal(t, []string{"one", "hundred", "five"}, numberWords(105))
+	assert.Nil(t, numberWords(1000))
+}
+
+func TestAnnouncementWords(t *testing.T) {
+	assert.Equal(t, []string{"three"}, announcementWords(internal.Announcement{Kind: internal.CountdownAnnouncement, Number: 3}))
+	assert.Equal(t, []string{"round", "twenty", "one"}, announcementWords(internal.Announcement{Kind: internal.WorkAnnouncement, Number: 21}))
+	assert.Equal(t, []string{"rest"}, announcementWords(internal.Announcement{Kind: internal.RestAnnouncement}))
+	assert.Equal(t, []string{"halfway"}, announcementWords(internal.Announcement{Kind: internal.HalfwayAnnouncement}))
+}